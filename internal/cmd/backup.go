@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/shellcmd"
+)
+
+func newBackupCmd(rootArgs *RootArgs) *cobra.Command {
+	var progress bool
+	var backupCmd = &cobra.Command{
+		SilenceUsage: true,
+		Use:          "backup <DB> <FILE>",
+		Short:        "Copy a libSQL or SQLite database into a new local SQLite file",
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDbCommand(cmd, args[0], rootArgs, func(config *shellcmd.DbCmdConfig) error {
+				return shellcmd.Backup(config, args[1], progress)
+			})
+		},
+	}
+
+	backupCmd.Flags().BoolVar(&progress, "progress", false, "Report backup progress on stderr")
+
+	return backupCmd
+}
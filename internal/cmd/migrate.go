@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/shellcmd"
+)
+
+func newMigrateCmd(rootArgs *RootArgs) *cobra.Command {
+	var dryRun bool
+	var migrateCmd = &cobra.Command{
+		SilenceUsage: true,
+		Use:          "migrate <DB> <DIR>",
+		Short:        "Apply the pending *.sql migrations of a directory to a database",
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDbCommand(cmd, args[0], rootArgs, func(config *shellcmd.DbCmdConfig) error {
+				return shellcmd.Migrate(config, args[1], dryRun)
+			})
+		},
+	}
+
+	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the statements of the pending migrations without applying them")
+
+	var statusCmd = &cobra.Command{
+		SilenceUsage: true,
+		Use:          "status <DB> <DIR>",
+		Short:        "List the migrations of a directory and whether they were applied to a database",
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDbCommand(cmd, args[0], rootArgs, func(config *shellcmd.DbCmdConfig) error {
+				return shellcmd.PrintMigrationStatus(config, args[1])
+			})
+		},
+	}
+	migrateCmd.AddCommand(statusCmd)
+
+	return migrateCmd
+}
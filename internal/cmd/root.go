@@ -7,6 +7,8 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
 
+	"github.com/libsql/libsql-shell-go/internal/db"
+	"github.com/libsql/libsql-shell-go/internal/shellcmd"
 	"github.com/libsql/libsql-shell-go/pkg/shell"
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
 )
@@ -16,6 +18,8 @@ type RootArgs struct {
 	quiet               bool
 	authToken           string
 	remoteEncryptionKey string
+	echo                bool
+	prompt              string
 }
 
 func NewRootCmd() *cobra.Command {
@@ -26,17 +30,7 @@ func NewRootCmd() *cobra.Command {
 		Short:        "A cli for executing SQL statements on a libSQL or SQLite database",
 		Args:         cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			shellConfig := shell.ShellConfig{
-				DbUri:               args[0],
-				InF:                 cmd.InOrStdin(),
-				OutF:                cmd.OutOrStdout(),
-				ErrF:                cmd.ErrOrStderr(),
-				HistoryMode:         enums.PerDatabaseHistory,
-				HistoryName:         "libsql",
-				QuietMode:           rootArgs.quiet,
-				AuthToken:           rootArgs.authToken,
-				RemoteEncryptionKey: rootArgs.remoteEncryptionKey,
-			}
+			shellConfig := newShellConfig(cmd, args[0], &rootArgs)
 
 			if cmd.Flag("exec").Changed {
 				if len(rootArgs.statements) == 0 {
@@ -52,12 +46,53 @@ func NewRootCmd() *cobra.Command {
 
 	rootCmd.Flags().StringVarP(&rootArgs.statements, "exec", "e", "", "SQL statements separated by ;")
 	rootCmd.Flags().BoolVarP(&rootArgs.quiet, "quiet", "q", false, "Don't print welcome message")
-	rootCmd.Flags().StringVar(&rootArgs.authToken, "auth", "", "Add a JWT Token.")
-	rootCmd.Flags().StringVar(&rootArgs.remoteEncryptionKey, "remote-encryption-key", "", "Add an encryption key for encrypted databases.")
+	rootCmd.Flags().BoolVar(&rootArgs.echo, "echo", false, "Print each statement before its results")
+	rootCmd.Flags().StringVar(&rootArgs.prompt, "prompt", "", "Prompt of new statements. It may contain the placeholders {db}, {conn}, {mode} and {tx}")
+	rootCmd.PersistentFlags().StringVar(&rootArgs.authToken, "auth", "", "Add a JWT Token.")
+	rootCmd.PersistentFlags().StringVar(&rootArgs.remoteEncryptionKey, "remote-encryption-key", "", "Add an encryption key for encrypted databases.")
+
+	// a database file named like a subcommand is only opened when given as a path, e.g. ./backup, so cobra's own
+	// completion and help subcommands are left out to keep those names free
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.SetHelpCommand(&cobra.Command{Hidden: true})
+	rootCmd.AddCommand(newBackupCmd(&rootArgs), newSchemaDiffCmd(&rootArgs), newMigrateCmd(&rootArgs))
 
 	return rootCmd
 }
 
+func newShellConfig(cmd *cobra.Command, dbUri string, rootArgs *RootArgs) shell.ShellConfig {
+	return shell.ShellConfig{
+		DbUri:               dbUri,
+		InF:                 cmd.InOrStdin(),
+		OutF:                cmd.OutOrStdout(),
+		ErrF:                cmd.ErrOrStderr(),
+		HistoryMode:         enums.PerDatabaseHistory,
+		HistoryName:         "libsql",
+		QuietMode:           rootArgs.quiet,
+		AuthToken:           rootArgs.authToken,
+		RemoteEncryptionKey: rootArgs.remoteEncryptionKey,
+		Echo:                rootArgs.echo,
+		PromptTemplate:      rootArgs.prompt,
+	}
+}
+
+// runDbCommand opens the database and runs a subcommand with the configuration dot commands use, so its arguments are
+// passed as parsed instead of through a command line
+func runDbCommand(cmd *cobra.Command, dbUri string, rootArgs *RootArgs, run func(config *shellcmd.DbCmdConfig) error) error {
+	database, err := db.NewDb(dbUri, rootArgs.authToken, "", false, rootArgs.remoteEncryptionKey)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	return run(&shellcmd.DbCmdConfig{
+		Db:      database,
+		OutF:    cmd.OutOrStdout(),
+		ErrF:    cmd.ErrOrStderr(),
+		GetMode: func() enums.PrintMode { return enums.TABLE_MODE },
+	})
+}
+
 func Execute() {
 	var rootCmd *cobra.Command = NewRootCmd()
 
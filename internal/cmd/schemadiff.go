@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/shellcmd"
+)
+
+func newSchemaDiffCmd(rootArgs *RootArgs) *cobra.Command {
+	var targetAuthToken string
+	var schemaDiffCmd = &cobra.Command{
+		SilenceUsage: true,
+		Use:          "schemadiff <DB> <TARGET_DB>",
+		Short:        "Print the SQL that migrates the schema of a database into the schema of another one",
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDbCommand(cmd, args[0], rootArgs, func(config *shellcmd.DbCmdConfig) error {
+				return shellcmd.PrintSchemaDiff(config, args[1], targetAuthToken, false)
+			})
+		},
+	}
+
+	schemaDiffCmd.Flags().StringVar(&targetAuthToken, "target-auth", "", "Auth token of the target database")
+
+	return schemaDiffCmd
+}
@@ -0,0 +1,613 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// userSchemaFilter excludes the objects managed by SQLite, litestream and libsql
+const userSchemaFilter = `name NOT LIKE 'sqlite_%'
+	AND name != '_litestream_seq'
+	AND name != '_litestream_lock'
+	AND name != 'libsql_wasm_func_table'`
+
+const defaultCopyBatchSize = 100
+
+// maxBoundParameters is SQLITE_MAX_VARIABLE_NUMBER of SQLite builds since 3.32.0
+const maxBoundParameters = 32766
+
+type CopyOptions struct {
+	// BatchSize is the maximum number of rows inserted by each INSERT statement
+	BatchSize int
+	// Truncate drops every table and view of the destination before copying
+	Truncate bool
+	// Progress is called after the records of each table are copied
+	Progress func(tableName string, copiedRows int)
+}
+
+type CopyReport struct {
+	// Consistent is false when the source could not be read inside a single transaction
+	Consistent bool
+	Tables     []TableCopyReport
+}
+
+type TableCopyReport struct {
+	Name       string
+	SourceRows int64
+	CopiedRows int64
+}
+
+func (r CopyReport) TotalRows() int64 {
+	var total int64
+	for _, table := range r.Tables {
+		total += table.CopiedRows
+	}
+	return total
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryExecer interface {
+	queryer
+	execer
+}
+
+type schemaObject struct {
+	objectType string
+	name       string
+	tableName  string
+	sql        string
+}
+
+type BackupFileAlreadyExistsError struct {
+	Path string
+}
+
+func (e *BackupFileAlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s already exists. The backup must be written to a new file", e.Path)
+}
+
+// BackupToFile copies the schema and records of the database into a new local SQLite file
+func (db *Db) BackupToFile(filePath string, options CopyOptions) (CopyReport, error) {
+	if _, err := os.Stat(filePath); err == nil {
+		return CopyReport{}, &BackupFileAlreadyExistsError{Path: filePath}
+	} else if !os.IsNotExist(err) {
+		return CopyReport{}, err
+	}
+
+	destination, err := NewDb(filePath, "", "", false, "")
+	if err != nil {
+		return CopyReport{}, err
+	}
+	defer destination.Close()
+
+	report, err := db.CopyTo(destination, options)
+	if err != nil {
+		destination.Close()
+		os.Remove(filePath)
+		return CopyReport{}, err
+	}
+	return report, nil
+}
+
+type CopyIntoItselfError struct{}
+
+func (e *CopyIntoItselfError) Error() string {
+	return "the destination must be a different database"
+}
+
+type DestinationObjectExistsError struct {
+	ObjectType string
+	Name       string
+	Err        error
+}
+
+func (e *DestinationObjectExistsError) Error() string {
+	return fmt.Sprintf("failed to create %s %s in the destination: %v. Use truncate to drop the existing tables and views first", e.ObjectType, e.Name, e.Err)
+}
+
+// isAlreadyExistsError reports if a CREATE statement failed because the destination has an object with the same name
+func isAlreadyExistsError(err error) bool {
+	return strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "there is already")
+}
+
+// isSameDatabase compares local databases by absolute path, so ./t.db and t.db are the same file
+func (db *Db) isSameDatabase(other *Db) bool {
+	if db.IsRemote() || other.IsRemote() {
+		return db.Uri == other.Uri
+	}
+
+	path, otherPath := getLocalDatabasePath(db.Uri), getLocalDatabasePath(other.Uri)
+	if path == ":memory:" || otherPath == ":memory:" {
+		return false
+	}
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return db.Uri == other.Uri
+	}
+	otherAbsolutePath, err := filepath.Abs(otherPath)
+	if err != nil {
+		return db.Uri == other.Uri
+	}
+	return absolutePath == otherAbsolutePath
+}
+
+// getLocalDatabasePath strips the scheme and parameters of "file:" URIs
+func getLocalDatabasePath(uri string) string {
+	if !strings.HasPrefix(uri, "file:") {
+		return uri
+	}
+	path := strings.TrimPrefix(uri, "file:")
+	if queryStart := strings.Index(path, "?"); queryStart >= 0 {
+		path = path[:queryStart]
+	}
+	return path
+}
+
+// CopyTo copies the schema and records of the database into the destination, creating the objects in dependency order.
+// Local destinations are written in a single transaction, remote ones commit each INSERT batch
+func (db *Db) CopyTo(destination *Db, options CopyOptions) (CopyReport, error) {
+	if db.isSameDatabase(destination) {
+		return CopyReport{}, &CopyIntoItselfError{}
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultCopyBatchSize
+	}
+
+	ctx := context.Background()
+	report := CopyReport{Consistent: true}
+
+	// Reading everything inside one transaction gives a consistent snapshot of the source.
+	// Read only transactions aren't supported by every driver, so we just never commit it
+	var source queryer = db.sqlDb
+	if sourceTx, err := db.sqlDb.BeginTx(ctx, nil); err == nil {
+		defer sourceTx.Rollback()
+		source = sourceTx
+	} else {
+		report.Consistent = false
+	}
+
+	objects, err := getSchemaObjects(ctx, source)
+	if err != nil {
+		return CopyReport{}, err
+	}
+	// shadow tables are created along with their virtual table, whose rows are copied instead
+	shadowTables := getShadowTables(ctx, source)
+	objects = withoutShadowTables(objects, shadowTables)
+	objects, err = sortSchemaObjectsByDependency(ctx, source, objects)
+	if err != nil {
+		return CopyReport{}, err
+	}
+
+	var target queryExecer = destination.sqlDb
+	var destinationTx *sql.Tx
+	if !destination.IsRemote() {
+		if destinationTx, err = destination.sqlDb.BeginTx(ctx, nil); err != nil {
+			return CopyReport{}, err
+		}
+		defer destinationTx.Rollback()
+		target = destinationTx
+	}
+
+	if options.Truncate {
+		if err := dropSchemaObjects(ctx, target); err != nil {
+			return CopyReport{}, err
+		}
+	}
+
+	// Tables are filled before creating indexes and triggers, so inserts are faster and triggers don't fire
+	for _, objectType := range []string{"table", "index", "view", "trigger"} {
+		for _, object := range objects {
+			if object.objectType != objectType {
+				continue
+			}
+			if _, err := target.ExecContext(ctx, object.sql); err != nil {
+				if isAlreadyExistsError(err) {
+					return CopyReport{}, &DestinationObjectExistsError{ObjectType: object.objectType, Name: object.name, Err: err}
+				}
+				return CopyReport{}, fmt.Errorf("failed to create %s %s in the destination: %w", object.objectType, object.name, err)
+			}
+			// only virtual tables keeping their content in shadow tables, like FTS ones, have rows of their own
+			if objectType != "table" || (isVirtualTable(object) && !hasShadowTables(object, shadowTables)) {
+				continue
+			}
+
+			tableReport, err := copyTableRecords(ctx, source, target, object.name, options.BatchSize)
+			if err != nil {
+				return CopyReport{}, fmt.Errorf("failed to copy records of table %s: %w", object.name, err)
+			}
+			report.Tables = append(report.Tables, tableReport)
+			if options.Progress != nil {
+				options.Progress(object.name, int(tableReport.CopiedRows))
+			}
+		}
+	}
+
+	if err := copySequences(ctx, source, target); err != nil {
+		return CopyReport{}, err
+	}
+
+	if destinationTx != nil {
+		if err := destinationTx.Commit(); err != nil {
+			return CopyReport{}, err
+		}
+	}
+
+	if err := verifyCopiedRows(ctx, source, destination.sqlDb, report.Tables); err != nil {
+		return CopyReport{}, err
+	}
+
+	return report, nil
+}
+
+func getSchemaObjects(ctx context.Context, source queryer) ([]schemaObject, error) {
+	rows, err := source.QueryContext(ctx, `SELECT type, name, tbl_name, sql FROM sqlite_schema
+		WHERE sql IS NOT NULL AND `+userSchemaFilter+` ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := make([]schemaObject, 0)
+	for rows.Next() {
+		var object schemaObject
+		if err := rows.Scan(&object.objectType, &object.name, &object.tableName, &object.sql); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, rows.Err()
+}
+
+// sortSchemaObjectsByDependency moves every table after the tables its foreign keys reference.
+// Tables in a reference cycle keep their creation order
+func sortSchemaObjectsByDependency(ctx context.Context, source queryer, objects []schemaObject) ([]schemaObject, error) {
+	tables := make(map[string]bool)
+	for _, object := range objects {
+		if object.objectType == "table" {
+			tables[object.name] = true
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for tableName := range tables {
+		referencedTables, err := getReferencedTables(ctx, source, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, referencedTable := range referencedTables {
+			if referencedTable != tableName && tables[referencedTable] {
+				dependencies[tableName] = append(dependencies[tableName], referencedTable)
+			}
+		}
+	}
+
+	sorted := make([]schemaObject, 0, len(objects))
+	added := make(map[string]bool)
+	visiting := make(map[string]bool)
+	objectsByName := make(map[string]schemaObject)
+	for _, object := range objects {
+		objectsByName[object.name] = object
+	}
+
+	var addTable func(tableName string)
+	addTable = func(tableName string) {
+		if added[tableName] || visiting[tableName] {
+			return
+		}
+		visiting[tableName] = true
+		for _, dependency := range dependencies[tableName] {
+			addTable(dependency)
+		}
+		visiting[tableName] = false
+		added[tableName] = true
+		sorted = append(sorted, objectsByName[tableName])
+	}
+
+	for _, object := range objects {
+		if object.objectType == "table" {
+			addTable(object.name)
+		} else {
+			sorted = append(sorted, object)
+		}
+	}
+
+	return sorted, nil
+}
+
+func getReferencedTables(ctx context.Context, source queryer, tableName string) ([]string, error) {
+	rows, err := source.QueryContext(ctx, `SELECT DISTINCT "table" FROM pragma_foreign_key_list(?)`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referencedTables := make([]string, 0)
+	for rows.Next() {
+		var referencedTable string
+		if err := rows.Scan(&referencedTable); err != nil {
+			return nil, err
+		}
+		referencedTables = append(referencedTables, referencedTable)
+	}
+
+	return referencedTables, rows.Err()
+}
+
+// dropSchemaObjects drops the views and then the tables of the database, dependent tables first.
+// Indexes and triggers are dropped with their tables
+func dropSchemaObjects(ctx context.Context, target queryExecer) error {
+	objects, err := getSchemaObjects(ctx, target)
+	if err != nil {
+		return err
+	}
+	// shadow tables are dropped with their virtual table
+	objects = withoutShadowTables(objects, getShadowTables(ctx, target))
+	objects, err = sortSchemaObjectsByDependency(ctx, target, objects)
+	if err != nil {
+		return err
+	}
+
+	for _, objectType := range []string{"view", "table"} {
+		for i := len(objects) - 1; i >= 0; i-- {
+			if objects[i].objectType != objectType {
+				continue
+			}
+			dropStmt := fmt.Sprintf("DROP %s IF EXISTS %s", strings.ToUpper(objectType), QuoteIdentifier(objects[i].name))
+			if _, err := target.ExecContext(ctx, dropStmt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getShadowTables returns the lowercase names of the tables where virtual tables keep their content.
+// pragma_table_list is only available since SQLite 3.37.0, so older databases report none
+func getShadowTables(ctx context.Context, source queryer) map[string]bool {
+	shadowTables := make(map[string]bool)
+	rows, err := source.QueryContext(ctx, "SELECT name FROM pragma_table_list WHERE type = 'shadow'")
+	if err != nil {
+		return shadowTables
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			shadowTables[strings.ToLower(name)] = true
+		}
+	}
+	return shadowTables
+}
+
+func withoutShadowTables(objects []schemaObject, shadowTables map[string]bool) []schemaObject {
+	filtered := make([]schemaObject, 0, len(objects))
+	for _, object := range objects {
+		if !shadowTables[strings.ToLower(object.name)] {
+			filtered = append(filtered, object)
+		}
+	}
+	return filtered
+}
+
+// hasShadowTables reports if the virtual table stores its content in the database. Shadow tables are named after
+// their virtual table, like f_content for the FTS table f
+func hasShadowTables(object schemaObject, shadowTables map[string]bool) bool {
+	prefix := strings.ToLower(object.name) + "_"
+	for name := range shadowTables {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVirtualTable(object schemaObject) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.Join(strings.Fields(object.sql), " ")), "CREATE VIRTUAL TABLE")
+}
+
+// getInsertableColumns skips generated and hidden columns, which can't be the target of an INSERT
+func getInsertableColumns(ctx context.Context, source queryer, tableName string) ([]string, error) {
+	rows, err := source.QueryContext(ctx, "SELECT name FROM pragma_table_xinfo(?) WHERE hidden = 0 ORDER BY cid", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]string, 0)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, QuoteIdentifier(column))
+	}
+
+	return columns, rows.Err()
+}
+
+func copyTableRecords(ctx context.Context, source queryer, target execer, tableName string, batchSize int) (TableCopyReport, error) {
+	report := TableCopyReport{Name: tableName}
+
+	columns, err := getInsertableColumns(ctx, source, tableName)
+	if err != nil {
+		return report, err
+	}
+	columnList := strings.Join(columns, ", ")
+	rowPlaceholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	if maxRows := maxBoundParameters / len(columns); batchSize > maxRows {
+		batchSize = maxRows
+	}
+
+	pendingArgs := make([]interface{}, 0, batchSize*len(columns))
+	flushPendingRows := func() error {
+		if len(pendingArgs) == 0 {
+			return nil
+		}
+		rowCount := len(pendingArgs) / len(columns)
+		insertStmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", QuoteIdentifier(tableName), columnList,
+			strings.TrimSuffix(strings.Repeat(rowPlaceholders+", ", rowCount), ", "))
+		if _, err := target.ExecContext(ctx, insertStmt, pendingArgs...); err != nil {
+			return err
+		}
+		report.CopiedRows += int64(rowCount)
+		pendingArgs = pendingArgs[:0]
+		return nil
+	}
+
+	rows, err := source.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", columnList, QuoteIdentifier(tableName)))
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(columns))
+	valuePointers := make([]interface{}, len(columns))
+	for i := range values {
+		valuePointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(valuePointers...); err != nil {
+			return report, err
+		}
+		args, err := normalizeValues(values)
+		if err != nil {
+			return report, err
+		}
+		pendingArgs = append(pendingArgs, args...)
+		if len(pendingArgs) == batchSize*len(columns) {
+			if err := flushPendingRows(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	return report, flushPendingRows()
+}
+
+// normalizeValues converts the values read by the libsql driver into values every driver accepts as arguments
+func normalizeValues(values []interface{}) ([]interface{}, error) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		if valueMap, isMap := value.(map[string]interface{}); isMap {
+			base64Value, ok := valueMap["base64"].(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported map value")
+			}
+			decoded, err := decodeBase64(base64Value)
+			if err != nil {
+				return nil, err
+			}
+			value = decoded
+		}
+		args[i] = value
+	}
+	return args, nil
+}
+
+// copySequences keeps the AUTOINCREMENT counters of the source, which may be ahead of the copied records
+func copySequences(ctx context.Context, source queryer, target execer) error {
+	// the table only exists when some table uses AUTOINCREMENT
+	exists, err := hasSequenceTable(ctx, source)
+	if err != nil || !exists {
+		return err
+	}
+
+	rows, err := source.QueryContext(ctx, "SELECT name, seq FROM sqlite_sequence")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var seq int64
+		if err := rows.Scan(&name, &seq); err != nil {
+			return err
+		}
+		if _, err := target.ExecContext(ctx, "DELETE FROM sqlite_sequence WHERE name = ?", name); err != nil {
+			return err
+		}
+		if _, err := target.ExecContext(ctx, "INSERT INTO sqlite_sequence (name, seq) VALUES (?, ?)", name, seq); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func hasSequenceTable(ctx context.Context, source queryer) (bool, error) {
+	rows, err := source.QueryContext(ctx, "SELECT 1 FROM sqlite_schema WHERE type = 'table' AND name = 'sqlite_sequence'")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	exists := rows.Next()
+	return exists, rows.Err()
+}
+
+type CopyVerificationError struct {
+	TableName  string
+	SourceRows int64
+	CopiedRows int64
+}
+
+func (e *CopyVerificationError) Error() string {
+	return fmt.Sprintf("verification failed for table %s: source has %d rows but the copy has %d", e.TableName, e.SourceRows, e.CopiedRows)
+}
+
+func verifyCopiedRows(ctx context.Context, source queryer, destination queryer, tables []TableCopyReport) error {
+	for i := range tables {
+		table := &tables[i]
+
+		sourceRows, err := countRows(ctx, source, table.Name)
+		if err != nil {
+			return err
+		}
+		destinationRows, err := countRows(ctx, destination, table.Name)
+		if err != nil {
+			return err
+		}
+
+		table.SourceRows = sourceRows
+		if sourceRows != destinationRows || destinationRows != table.CopiedRows {
+			return &CopyVerificationError{TableName: table.Name, SourceRows: sourceRows, CopiedRows: destinationRows}
+		}
+	}
+
+	return nil
+}
+
+func countRows(ctx context.Context, q queryer, tableName string) (int64, error) {
+	rows, err := q.QueryContext(ctx, "SELECT count(*) FROM "+QuoteIdentifier(tableName))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+	return count, rows.Err()
+}
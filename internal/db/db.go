@@ -31,7 +31,20 @@ type Db struct {
 	driver    driver
 	urlScheme string
 
+	// proxy, schemaDb and remoteEncryptionKey are the connection options of NewDb, see OpenWithSameOptions
+	proxy               string
+	schemaDb            bool
+	remoteEncryptionKey string
+
 	cancelRunningQuery func()
+	// abandonLastResult stops the goroutine populating the last StatementsResult, see executeQueries
+	abandonLastResult func()
+
+	parameters Parameters
+
+	transactionStack []TransactionLevel
+	// bufferedTransaction holds the statements of the transaction open on an HTTP connection, see bufferHttpTransaction
+	bufferedTransaction []string
 }
 
 func (db *Db) IsRemote() bool {
@@ -74,7 +87,7 @@ func newRowResultWithError(err error) *rowResult {
 func NewDb(dbUri, authToken, proxy string, schemaDb bool, remoteEncryptionKey string) (*Db, error) {
 	var err error
 
-	var db = Db{Uri: dbUri, AuthToken: authToken}
+	var db = Db{Uri: dbUri, AuthToken: authToken, proxy: proxy, schemaDb: schemaDb, remoteEncryptionKey: remoteEncryptionKey}
 
 	if IsUrl(dbUri) {
 		var validSqldUrl bool
@@ -104,6 +117,11 @@ func NewDb(dbUri, authToken, proxy string, schemaDb bool, remoteEncryptionKey st
 	} else {
 		db.driver = sqlite3Driver
 		db.sqlDb, err = sql.Open("sqlite3", dbUri)
+		if err == nil {
+			// ATTACH, TEMP objects and transactions belong to a single SQLite connection,
+			// so every statement must run on the same one
+			db.sqlDb.SetMaxOpenConns(1)
+		}
 	}
 	if err != nil {
 		return nil, err
@@ -111,6 +129,12 @@ func NewDb(dbUri, authToken, proxy string, schemaDb bool, remoteEncryptionKey st
 	return &db, nil
 }
 
+// OpenWithSameOptions opens another database with the proxy, schema database and remote encryption key of this one, so
+// every connection of the shell reaches its database the same way
+func (db *Db) OpenWithSameOptions(dbUri, authToken string) (*Db, error) {
+	return NewDb(dbUri, authToken, db.proxy, db.schemaDb, db.remoteEncryptionKey)
+}
+
 func (db *Db) TestConnection() error {
 	_, err := db.sqlDb.Exec("SELECT 1;")
 	if err != nil {
@@ -119,26 +143,77 @@ func (db *Db) TestConnection() error {
 	return nil
 }
 
+// GetSchemaNames returns the name of the main database followed by the attached ones
+func (db *Db) GetSchemaNames() ([]string, error) {
+	// remote databases can't attach other databases
+	if db.driver != sqlite3Driver {
+		return []string{"main"}, nil
+	}
+
+	rows, err := db.sqlDb.Query("SELECT name FROM pragma_database_list ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemaNames := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		schemaNames = append(schemaNames, name)
+	}
+	return schemaNames, rows.Err()
+}
+
+// SetParameters sets the values bound to the parameters of the statements executed from now on
+func (db *Db) SetParameters(parameters Parameters) {
+	db.parameters = parameters
+}
+
 func (db *Db) Close() {
 	db.sqlDb.Close()
 }
 
+// ExecuteStatements executes the statements right away, even while a transaction typed over HTTP is buffered, so the
+// queries of the shell commands aren't held back with it
 func (db *Db) ExecuteStatements(statementsString string) (StatementsResult, error) {
-	queries := db.prepareStatementsIntoQueries(statementsString)
+	return db.executeQueries(db.prepareStatementsIntoQueries(statementsString))
+}
+
+// ExecuteTypedStatements executes the statements typed by the user. Over HTTP, the transactions they open are buffered
+// until they are committed, see bufferHttpTransaction
+func (db *Db) ExecuteTypedStatements(statementsString string) (StatementsResult, error) {
+	if db.isHttp() {
+		return db.executeQueries(db.bufferHttpTransaction(statementsString))
+	}
+	return db.ExecuteStatements(statementsString)
+}
+
+// executeQueries populates the result from a goroutine. A reader that stops reading it early would block the goroutine
+// and its connection forever, which is the only one of local databases, so the last result is abandoned when the next
+// queries are executed. Results must then be read before executing anything else
+func (db *Db) executeQueries(queries []string) (StatementsResult, error) {
+	if db.abandonLastResult != nil {
+		db.abandonLastResult()
+	}
+	ctx, abandon := context.WithCancel(context.Background())
+	db.abandonLastResult = abandon
 
 	statementResultCh := make(chan StatementResult)
 
 	go func() {
 		defer close(statementResultCh)
-		db.executeQueriesAndPopulateChannel(queries, statementResultCh)
+		db.executeQueriesAndPopulateChannel(ctx, queries, statementResultCh)
 	}()
 
 	return StatementsResult{StatementResultCh: statementResultCh}, nil
 }
 
-func (db *Db) executeQueriesAndPopulateChannel(queries []string, statementResultCh chan StatementResult) {
+func (db *Db) executeQueriesAndPopulateChannel(ctx context.Context, queries []string, statementResultCh chan StatementResult) {
 	for _, query := range queries {
-		if shouldContinue := db.executeQuery(query, statementResultCh); !shouldContinue {
+		if shouldContinue := db.executeQuery(ctx, query, statementResultCh); !shouldContinue {
 			return
 		}
 	}
@@ -158,24 +233,118 @@ func (db *Db) ExecuteAndPrintStatements(statementsString string, outF io.Writer,
 	return nil
 }
 
-func (db *Db) executeQuery(query string, statementResultCh chan StatementResult) (queryEndedWithoutError bool) {
+// ExecuteAndPrintTypedStatements is ExecuteAndPrintStatements for the statements typed by the user
+func (db *Db) ExecuteAndPrintTypedStatements(statementsString string, outF io.Writer, withoutHeader bool, printMode enums.PrintMode) error {
+	result, err := db.ExecuteTypedStatements(statementsString)
+	if err != nil {
+		return err
+	}
+	return PrintStatementsResult(result, outF, withoutHeader, printMode)
+}
+
+// ExecuteAndPrintStatementWithEcho prints a single typed statement before its results.
+// In JSON mode the results are wrapped in an object that also holds the statement
+func (db *Db) ExecuteAndPrintStatementWithEcho(statement string, outF io.Writer, withoutHeader bool, printMode enums.PrintMode) error {
+	statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+	if printMode != enums.JSON_MODE {
+		fmt.Fprintln(outF, statement+";")
+		return db.ExecuteAndPrintTypedStatements(statement, outF, withoutHeader, printMode)
+	}
+
+	result, err := db.ExecuteTypedStatements(statement)
+	if err != nil {
+		return err
+	}
+	for statementResult := range result.StatementResultCh {
+		if statementResult.Err != nil {
+			return statementResult.Err
+		}
+		if err := (EchoJSONPrinter{statement: statement}).print(statementResult, outF); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteEachStatement calls execute with each statement on its own, reporting the failing ones on errF
+func ExecuteEachStatement(statementsString string, errF io.Writer, execute func(statement string) error) error {
+	statements, _ := sqliteparserutils.SplitStatement(statementsString)
+
+	executed, failed := 0, 0
+	for _, statement := range statements {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		executed++
+
+		if err := execute(statement); err != nil {
+			failed++
+			PrintError(&StatementError{Index: executed, Statement: statement, Err: err}, errF)
+		}
+	}
+
+	if failed > 0 {
+		return &FailedStatementsError{Failed: failed, Total: executed}
+	}
+	return nil
+}
+
+// ExecuteStatementsDiscardingRows executes the statements without printing their results. They go through the buffer of
+// ExecuteTypedStatements, since they open or close transactions on behalf of the user.
+// It returns how many statement results were read before the first error
+func (db *Db) ExecuteStatementsDiscardingRows(statementsString string) (succeeded int, err error) {
+	result, err := db.ExecuteTypedStatements(statementsString)
+	if err != nil {
+		return 0, err
+	}
+
+	// Every channel must be drained, otherwise the goroutine populating them would be blocked forever
+	for statementResult := range result.StatementResultCh {
+		if statementResult.Err != nil {
+			err = statementResult.Err
+			continue
+		}
+
+		var rowErr error
+		for rowResult := range statementResult.RowCh {
+			if rowResult.Err != nil && rowErr == nil {
+				rowErr = rowResult.Err
+			}
+		}
+		if rowErr != nil {
+			err = rowErr
+			continue
+		}
+
+		succeeded++
+	}
+
+	return succeeded, err
+}
+
+func (db *Db) executeQuery(resultCtx context.Context, query string, statementResultCh chan StatementResult) (queryEndedWithoutError bool) {
 	if strings.TrimSpace(query) == "" {
 		return true
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(resultCtx)
 	db.cancelRunningQuery = cancel
 
-	rows, err := db.sqlDb.QueryContext(ctx, query)
+	rows, err := db.sqlDb.QueryContext(ctx, query, getParameterArgs(query, db.parameters)...)
 	if err != nil {
-		statementResultCh <- *newStatementResultWithError(err)
+		sendStatementResult(resultCtx, statementResultCh, *newStatementResultWithError(err))
 
 		return false
 	}
 
 	defer rows.Close()
 
-	return readQueryResults(rows, statementResultCh)
+	queryEndedWithoutError = readQueryResults(resultCtx, rows, statementResultCh)
+	if queryEndedWithoutError {
+		db.trackTransaction(query)
+	}
+	return queryEndedWithoutError
 }
 
 func (db *Db) prepareStatementsIntoQueries(statementsString string) []string {
@@ -183,9 +352,12 @@ func (db *Db) prepareStatementsIntoQueries(statementsString string) []string {
 	// e.g If we execute query "select 1; select 2;" with it, just the first one ("select 1;") would be executed
 	//
 	// libsql driver doesn't accept multiple statements if using websocket connection
+	//
+	// Parameters are bound to each statement on its own
 	mustSplitStatementsIntoMultipleQueries :=
 		db.driver == sqlite3Driver ||
-			db.driver == libsqlDriver && (db.urlScheme == "libsql" || db.urlScheme == "wss" || db.urlScheme == "ws")
+			db.driver == libsqlDriver && (db.urlScheme == "libsql" || db.urlScheme == "wss" || db.urlScheme == "ws") ||
+			hasBindParameters(statementsString)
 
 	if mustSplitStatementsIntoMultipleQueries {
 		stmts, _ := sqliteparserutils.SplitStatement(statementsString)
@@ -223,10 +395,10 @@ func getColumnTypes(rows *sql.Rows) ([]reflect.Type, error) {
 	return types, nil
 }
 
-func readQueryResults(queryRows *sql.Rows, statementResultCh chan StatementResult) (shouldContinue bool) {
+func readQueryResults(ctx context.Context, queryRows *sql.Rows, statementResultCh chan StatementResult) (shouldContinue bool) {
 	hasResultSetToRead := true
 	for hasResultSetToRead {
-		if shouldContinue := readQueryResultSet(queryRows, statementResultCh); !shouldContinue {
+		if shouldContinue := readQueryResultSet(ctx, queryRows, statementResultCh); !shouldContinue {
 			return false
 		}
 
@@ -234,23 +406,23 @@ func readQueryResults(queryRows *sql.Rows, statementResultCh chan StatementResul
 	}
 
 	if err := queryRows.Err(); err != nil {
-		statementResultCh <- *newStatementResultWithError(err)
+		sendStatementResult(ctx, statementResultCh, *newStatementResultWithError(err))
 		return false
 	}
 
 	return true
 }
 
-func readQueryResultSet(queryRows *sql.Rows, statementResultCh chan StatementResult) (shouldContinue bool) {
+func readQueryResultSet(ctx context.Context, queryRows *sql.Rows, statementResultCh chan StatementResult) (shouldContinue bool) {
 	columnNames, err := getColumnNames(queryRows)
 	if err != nil {
-		statementResultCh <- *newStatementResultWithError(err)
+		sendStatementResult(ctx, statementResultCh, *newStatementResultWithError(err))
 		return false
 	}
 
 	columnTypes, err := getColumnTypes(queryRows)
 	if err != nil {
-		statementResultCh <- *newStatementResultWithError(err)
+		sendStatementResult(ctx, statementResultCh, *newStatementResultWithError(err))
 		return false
 	}
 
@@ -267,12 +439,14 @@ func readQueryResultSet(queryRows *sql.Rows, statementResultCh chan StatementRes
 	rowCh := make(chan rowResult)
 	defer close(rowCh)
 
-	statementResultCh <- *newStatementResult(columnNames, rowCh)
+	if !sendStatementResult(ctx, statementResultCh, *newStatementResult(columnNames, rowCh)) {
+		return false
+	}
 
 	for queryRows.Next() {
 		err = queryRows.Scan(columnPointers...)
 		if err != nil {
-			rowCh <- *newRowResultWithError(err)
+			sendRowResult(ctx, rowCh, *newRowResultWithError(err))
 			return false
 		}
 
@@ -281,17 +455,39 @@ func readQueryResultSet(queryRows *sql.Rows, statementResultCh chan StatementRes
 			val := reflect.ValueOf(ptr).Elem()
 			rowData[i] = val.Interface()
 		}
-		rowCh <- *newRowResult(rowData)
+		if !sendRowResult(ctx, rowCh, *newRowResult(rowData)) {
+			return false
+		}
 	}
 
 	if err := queryRows.Err(); err != nil {
-		rowCh <- *newRowResultWithError(err)
+		sendRowResult(ctx, rowCh, *newRowResultWithError(err))
 		return false
 	}
 
 	return true
 }
 
+// sendStatementResult returns false without sending when the result was abandoned
+func sendStatementResult(ctx context.Context, statementResultCh chan StatementResult, result StatementResult) bool {
+	select {
+	case statementResultCh <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendRowResult returns false without sending when the result was abandoned
+func sendRowResult(ctx context.Context, rowCh chan rowResult, row rowResult) bool {
+	select {
+	case rowCh <- row:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func (db *Db) CancelQuery() {
 	if db.cancelRunningQuery != nil {
 		db.cancelRunningQuery()
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type ColumnDescription struct {
+	Name         string
+	Type         string
+	NotNull      bool
+	DefaultValue sql.NullString
+	// PrimaryKey is the position of the column in the primary key, or 0 if it is not part of it
+	PrimaryKey  int
+	Kind        ColumnKind
+	ForeignKeys []string
+	Indexes     []string
+}
+
+// ColumnKind is the "hidden" value of PRAGMA table_xinfo
+type ColumnKind int
+
+const (
+	NormalColumn ColumnKind = iota
+	HiddenColumn
+	GeneratedVirtualColumn
+	GeneratedStoredColumn
+)
+
+func (k ColumnKind) String() string {
+	switch k {
+	case NormalColumn:
+		return "normal"
+	case HiddenColumn:
+		return "hidden"
+	case GeneratedVirtualColumn:
+		return "generated virtual"
+	case GeneratedStoredColumn:
+		return "generated stored"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(k))
+	}
+}
+
+type TableNotFoundError struct {
+	Name string
+}
+
+func (e *TableNotFoundError) Error() string {
+	return fmt.Sprintf("table %s does not exist", e.Name)
+}
+
+// DescribeTable returns the columns of a table with their constraints, the foreign keys they belong to and the indexes covering them
+func (db *Db) DescribeTable(schema string, tableName string) ([]ColumnDescription, error) {
+	ctx := context.Background()
+
+	columns, err := getColumnDescriptions(ctx, db.sqlDb, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, &TableNotFoundError{Name: tableName}
+	}
+
+	columnsByName := make(map[string]*ColumnDescription, len(columns))
+	for i := range columns {
+		columnsByName[strings.ToLower(columns[i].Name)] = &columns[i]
+	}
+
+	if err := addForeignKeys(ctx, db.sqlDb, schema, tableName, columnsByName); err != nil {
+		return nil, err
+	}
+	if err := addIndexes(ctx, db.sqlDb, schema, tableName, columnsByName); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+func getColumnDescriptions(ctx context.Context, source queryer, schema string, tableName string) ([]ColumnDescription, error) {
+	rows, err := source.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?, ?) ORDER BY cid`, tableName, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]ColumnDescription, 0)
+	for rows.Next() {
+		var column ColumnDescription
+		if err := rows.Scan(&column.Name, &column.Type, &column.NotNull, &column.DefaultValue, &column.PrimaryKey, &column.Kind); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+func addForeignKeys(ctx context.Context, source queryer, schema string, tableName string, columnsByName map[string]*ColumnDescription) error {
+	rows, err := source.QueryContext(ctx, `SELECT "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, tableName, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var referencedTable, from, onUpdate, onDelete string
+		var to sql.NullString
+		if err := rows.Scan(&referencedTable, &from, &to, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+
+		column, ok := columnsByName[strings.ToLower(from)]
+		if !ok {
+			continue
+		}
+
+		// without a target column, the foreign key references the primary key of the table
+		reference := referencedTable
+		if to.Valid {
+			reference = fmt.Sprintf("%s(%s)", referencedTable, to.String)
+		}
+		if onUpdate != "NO ACTION" {
+			reference += " ON UPDATE " + onUpdate
+		}
+		if onDelete != "NO ACTION" {
+			reference += " ON DELETE " + onDelete
+		}
+		column.ForeignKeys = append(column.ForeignKeys, reference)
+	}
+	return rows.Err()
+}
+
+type IndexDescription struct {
+	Name    string
+	Table   string
+	Unique  bool
+	Partial bool
+	// Origin is "c" for CREATE INDEX, "u" for UNIQUE constraints and "pk" for PRIMARY KEY constraints
+	Origin string
+	// Columns only has the named columns of the index, HasExpressions tells if it also indexes expressions
+	Columns        []string
+	HasExpressions bool
+}
+
+// GetIndexes returns the indexes of a table, including the ones created for UNIQUE and PRIMARY KEY constraints
+func (db *Db) GetIndexes(schema string, tableName string) ([]IndexDescription, error) {
+	return getIndexes(context.Background(), db.sqlDb, schema, tableName)
+}
+
+func getIndexes(ctx context.Context, source queryer, schema string, tableName string) ([]IndexDescription, error) {
+	rows, err := source.QueryContext(ctx, `SELECT name, "unique", origin, partial FROM pragma_index_list(?, ?) ORDER BY name`, tableName, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]IndexDescription, 0)
+	for rows.Next() {
+		index := IndexDescription{Table: tableName}
+		if err := rows.Scan(&index.Name, &index.Unique, &index.Origin, &index.Partial); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the index columns are read after closing the index list, since the connection of a local database can't be shared
+	for i := range indexes {
+		if indexes[i].Columns, indexes[i].HasExpressions, err = getIndexKeyColumns(ctx, source, schema, indexes[i].Name); err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+func getIndexKeyColumns(ctx context.Context, source queryer, schema string, indexName string) (columns []string, hasExpressions bool, err error) {
+	rows, err := source.QueryContext(ctx, "SELECT name FROM pragma_index_info(?, ?) ORDER BY seqno", indexName, schema)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	columns = make([]string, 0)
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, false, err
+		}
+		// expression columns have no name
+		if !name.Valid {
+			hasExpressions = true
+			continue
+		}
+		columns = append(columns, name.String)
+	}
+	return columns, hasExpressions, rows.Err()
+}
+
+func addIndexes(ctx context.Context, source queryer, schema string, tableName string, columnsByName map[string]*ColumnDescription) error {
+	indexes, err := getIndexes(ctx, source, schema, tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		description := index.Name
+		if index.Unique {
+			description += " (unique)"
+		}
+		for _, indexColumn := range index.Columns {
+			if column, ok := columnsByName[strings.ToLower(indexColumn)]; ok {
+				column.Indexes = append(column.Indexes, description)
+			}
+		}
+	}
+	return nil
+}
+
+type ForeignKey struct {
+	Table   string
+	Columns []string
+	// ReferencedColumns is empty when the foreign key references the primary key of ReferencedTable
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// GetTableNames returns the user tables of the schema whose name matches the LIKE pattern, or all of them if pattern is empty
+func (db *Db) GetTableNames(schema string, pattern string) ([]string, error) {
+	query := fmt.Sprintf(`SELECT name FROM %s.sqlite_schema
+		WHERE type = 'table'
+		AND name NOT LIKE 'sqlite_%%'
+		AND name != '_litestream_seq'
+		AND name != '_litestream_lock'
+		AND name != 'libsql_wasm_func_table'
+		AND name LIKE ?
+		ORDER BY name`, QuoteIdentifier(schema))
+	if pattern == "" {
+		pattern = "%"
+	}
+
+	rows, err := db.sqlDb.QueryContext(context.Background(), query, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// GetForeignKeys returns the foreign keys declared by a table, with the columns of composite keys grouped together
+func (db *Db) GetForeignKeys(schema string, tableName string) ([]ForeignKey, error) {
+	rows, err := db.sqlDb.QueryContext(context.Background(),
+		`SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, tableName, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]ForeignKey, 0)
+	lastId := -1
+	for rows.Next() {
+		var id int
+		var referencedTable, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &referencedTable, &from, &to); err != nil {
+			return nil, err
+		}
+
+		if id != lastId {
+			foreignKeys = append(foreignKeys, ForeignKey{Table: tableName, ReferencedTable: referencedTable})
+			lastId = id
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, from)
+		if to.Valid {
+			foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, to.String)
+		}
+	}
+	return foreignKeys, rows.Err()
+}
@@ -1,5 +1,7 @@
 package db
 
+import "fmt"
+
 type InvalidStatementsResult struct{}
 
 func (e *InvalidStatementsResult) Error() string {
@@ -17,3 +19,33 @@ func (e *UnableToPrintStatementResult) Error() string {
 func (e *UnableToPrintStatementResult) internalError() string {
 	return "unable to print statement result. You should check if its an error before printing it"
 }
+
+type StatementError struct {
+	// Index is the position of the statement in its input, starting at 1
+	Index     int
+	Statement string
+	Err       error
+	// File and Line locate the statement when it was read from a file
+	File string
+	Line int
+}
+
+func (e *StatementError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("statement %d at line %d of %s failed: %v\n  %s", e.Index, e.Line, e.File, e.Err, e.Statement)
+	}
+	return fmt.Sprintf("statement %d failed: %v\n  %s", e.Index, e.Err, e.Statement)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+type FailedStatementsError struct {
+	Failed int
+	Total  int
+}
+
+func (e *FailedStatementsError) Error() string {
+	return fmt.Sprintf("%d of %d statements failed", e.Failed, e.Total)
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type QueryPlanStep struct {
+	Id     int
+	Parent int
+	Detail string
+}
+
+type BytecodeInstruction struct {
+	Address int
+	Opcode  string
+	P1      int
+	P2      int
+	P3      int
+	P4      sql.NullString
+	P5      int
+	Comment sql.NullString
+	// Indent is the loop depth of the instruction, used to render loop bodies like the sqlite3 shell does
+	Indent int
+}
+
+// GetQueryPlan returns the steps of "EXPLAIN QUERY PLAN statement"
+func (db *Db) GetQueryPlan(statement string) ([]QueryPlanStep, error) {
+	rows, err := db.sqlDb.QueryContext(context.Background(), "EXPLAIN QUERY PLAN "+statement)
+	if err != nil {
+		return nil, treatDbError(err)
+	}
+	defer rows.Close()
+
+	steps := make([]QueryPlanStep, 0)
+	for rows.Next() {
+		var step QueryPlanStep
+		var notUsed interface{}
+		if err := rows.Scan(&step.Id, &step.Parent, &notUsed, &step.Detail); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// FormatQueryPlan renders the steps of a query plan as a tree
+func FormatQueryPlan(steps []QueryPlanStep) string {
+	children := make(map[int][]QueryPlanStep)
+	for _, step := range steps {
+		children[step.Parent] = append(children[step.Parent], step)
+	}
+
+	var tree strings.Builder
+	tree.WriteString("QUERY PLAN\n")
+	writeQueryPlanSteps(&tree, children, 0, "")
+	return tree.String()
+}
+
+func writeQueryPlanSteps(tree *strings.Builder, children map[int][]QueryPlanStep, parent int, prefix string) {
+	steps := children[parent]
+	for i, step := range steps {
+		connector, childPrefix := "|--", "|  "
+		if i == len(steps)-1 {
+			connector, childPrefix = "`--", "   "
+		}
+		tree.WriteString(prefix + connector + step.Detail + "\n")
+
+		// a step can't be its own parent, but guard against looping forever on an unexpected plan
+		if step.Id != parent {
+			writeQueryPlanSteps(tree, children, step.Id, prefix+childPrefix)
+		}
+	}
+}
+
+// opcodes jumping back to the start of a loop
+var loopOpcodes = map[string]bool{"Next": true, "Prev": true, "VNext": true, "VPrev": true, "SorterNext": true}
+
+// opcodes that start a loop when a Goto jumps back to them
+var loopStartOpcodes = map[string]bool{"Yield": true, "SeekLT": true, "SeekGT": true, "RowSetRead": true, "Rewind": true}
+
+// GetBytecode returns the instructions of "EXPLAIN statement" with the indentation of their loops
+func (db *Db) GetBytecode(statement string) ([]BytecodeInstruction, error) {
+	rows, err := db.sqlDb.QueryContext(context.Background(), "EXPLAIN "+statement)
+	if err != nil {
+		return nil, treatDbError(err)
+	}
+	defer rows.Close()
+
+	instructions := make([]BytecodeInstruction, 0)
+	for rows.Next() {
+		var instruction BytecodeInstruction
+		if err := rows.Scan(&instruction.Address, &instruction.Opcode, &instruction.P1, &instruction.P2, &instruction.P3,
+			&instruction.P4, &instruction.P5, &instruction.Comment); err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, instruction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	indentLoops(instructions)
+	return instructions, nil
+}
+
+func indentLoops(instructions []BytecodeInstruction) {
+	for i, instruction := range instructions {
+		target := instruction.P2
+		if target >= i || target < 0 {
+			continue
+		}
+
+		isLoop := loopOpcodes[instruction.Opcode] ||
+			instruction.Opcode == "Goto" && loopStartOpcodes[instructions[target].Opcode]
+		if !isLoop {
+			continue
+		}
+
+		for j := target; j < i; j++ {
+			instructions[j].Indent++
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+)
+
+const (
+	LintMissingPrimaryKey      = "missing_primary_key"
+	LintForeignKeyWithoutIndex = "foreign_key_without_index"
+	LintRedundantIndex         = "redundant_index"
+	LintInconsistentType       = "inconsistent_type"
+	LintFullTableScan          = "full_table_scan"
+)
+
+type LintIssue struct {
+	Check      string
+	Object     string
+	Message    string
+	Suggestion string
+}
+
+type lintTable struct {
+	name        string
+	columns     []ColumnDescription
+	foreignKeys []ForeignKey
+	indexes     []IndexDescription
+}
+
+// LintSchema reports tables without primary key, foreign keys without an index covering them,
+// indexes made redundant by another one and foreign keys declared with a different type than the columns they reference
+func (db *Db) LintSchema() ([]LintIssue, error) {
+	tables, err := db.getLintTables()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]LintIssue, 0)
+	issues = append(issues, lintMissingPrimaryKeys(tables)...)
+	issues = append(issues, lintForeignKeysWithoutIndex(tables)...)
+	issues = append(issues, lintRedundantIndexes(tables)...)
+	issues = append(issues, lintInconsistentTypes(tables)...)
+	return issues, nil
+}
+
+func (db *Db) getLintTables() ([]lintTable, error) {
+	ctx := context.Background()
+	objects, err := getSchemaObjects(ctx, db.sqlDb)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]lintTable, 0)
+	for _, object := range objects {
+		if object.objectType != "table" || isVirtualTable(object) {
+			continue
+		}
+
+		table := lintTable{name: object.name}
+		if table.columns, err = db.DescribeTable("main", object.name); err != nil {
+			return nil, err
+		}
+		if table.foreignKeys, err = db.GetForeignKeys("main", object.name); err != nil {
+			return nil, err
+		}
+		if table.indexes, err = db.GetIndexes("main", object.name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].name < tables[j].name })
+	return tables, nil
+}
+
+func (t lintTable) primaryKey() []string {
+	columns := make([]ColumnDescription, 0)
+	for _, column := range t.columns {
+		if column.PrimaryKey > 0 {
+			columns = append(columns, column)
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].PrimaryKey < columns[j].PrimaryKey })
+
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func (t lintTable) findColumn(name string) (ColumnDescription, bool) {
+	for _, column := range t.columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return ColumnDescription{}, false
+}
+
+func lintMissingPrimaryKeys(tables []lintTable) []LintIssue {
+	issues := make([]LintIssue, 0)
+	for _, table := range tables {
+		if len(table.primaryKey()) == 0 {
+			issues = append(issues, LintIssue{
+				Check:   LintMissingPrimaryKey,
+				Object:  table.name,
+				Message: fmt.Sprintf("table %s has no primary key, so its rows can only be identified by the rowid", table.name),
+			})
+		}
+	}
+	return issues
+}
+
+// isPrefixOf reports if the columns are the first columns of other, in any order
+func isPrefixOf(columns []string, other []string) bool {
+	if len(columns) == 0 || len(columns) > len(other) {
+		return false
+	}
+
+	prefix := make(map[string]bool, len(columns))
+	for _, column := range other[:len(columns)] {
+		prefix[strings.ToLower(column)] = true
+	}
+	for _, column := range columns {
+		if !prefix[strings.ToLower(column)] {
+			return false
+		}
+	}
+	return true
+}
+
+func lintForeignKeysWithoutIndex(tables []lintTable) []LintIssue {
+	issues := make([]LintIssue, 0)
+	for _, table := range tables {
+		for _, foreignKey := range table.foreignKeys {
+			// the primary key is an index by itself, even when it's the rowid
+			covered := isPrefixOf(foreignKey.Columns, table.primaryKey())
+			for _, index := range table.indexes {
+				if !index.Partial && isPrefixOf(foreignKey.Columns, index.Columns) {
+					covered = true
+				}
+			}
+			if covered {
+				continue
+			}
+
+			columns := strings.Join(foreignKey.Columns, ", ")
+			quotedColumns := make([]string, 0, len(foreignKey.Columns))
+			for _, column := range foreignKey.Columns {
+				quotedColumns = append(quotedColumns, QuoteIdentifier(column))
+			}
+			indexName := table.name + "_" + strings.Join(foreignKey.Columns, "_") + "_idx"
+			issues = append(issues, LintIssue{
+				Check:  LintForeignKeyWithoutIndex,
+				Object: table.name,
+				Message: fmt.Sprintf("foreign key %s(%s) referencing %s has no index, so deleting or updating %s rows scans %s",
+					table.name, columns, foreignKey.ReferencedTable, foreignKey.ReferencedTable, table.name),
+				Suggestion: fmt.Sprintf("CREATE INDEX %s ON %s (%s);", QuoteIdentifier(indexName), QuoteIdentifier(table.name), strings.Join(quotedColumns, ", ")),
+			})
+		}
+	}
+	return issues
+}
+
+func lintRedundantIndexes(tables []lintTable) []LintIssue {
+	issues := make([]LintIssue, 0)
+	for _, table := range tables {
+		for _, index := range table.indexes {
+			// indexes of constraints can't be dropped, and unique indexes enforce a constraint of their own
+			if index.Origin != "c" || index.Unique || index.Partial || index.HasExpressions {
+				continue
+			}
+
+			for _, other := range table.indexes {
+				if other.Name == index.Name || other.Partial || other.HasExpressions || !isPrefixOf(index.Columns, other.Columns) {
+					continue
+				}
+				// of two identical indexes, only the second one is reported
+				if len(index.Columns) == len(other.Columns) && other.Origin == "c" && !other.Unique && other.Name > index.Name {
+					continue
+				}
+
+				issues = append(issues, LintIssue{
+					Check:  LintRedundantIndex,
+					Object: index.Name,
+					Message: fmt.Sprintf("index %s on %s(%s) is covered by index %s on %s(%s)",
+						index.Name, table.name, strings.Join(index.Columns, ", "), other.Name, table.name, strings.Join(other.Columns, ", ")),
+					Suggestion: fmt.Sprintf("DROP INDEX %s;", QuoteIdentifier(index.Name)),
+				})
+				break
+			}
+		}
+	}
+	return issues
+}
+
+func normalizeType(columnType string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(columnType), " "))
+}
+
+// lintInconsistentTypes only compares columns linked by a foreign key, since columns sharing a name in unrelated tables
+// can hold different data on purpose
+func lintInconsistentTypes(tables []lintTable) []LintIssue {
+	issues := make([]LintIssue, 0)
+
+	tablesByName := make(map[string]lintTable, len(tables))
+	for _, table := range tables {
+		tablesByName[strings.ToLower(table.name)] = table
+	}
+
+	for _, table := range tables {
+		for _, foreignKey := range table.foreignKeys {
+			referencedTable, ok := tablesByName[strings.ToLower(foreignKey.ReferencedTable)]
+			if !ok {
+				continue
+			}
+			referencedColumns := foreignKey.ReferencedColumns
+			if len(referencedColumns) == 0 {
+				referencedColumns = referencedTable.primaryKey()
+			}
+
+			for i, columnName := range foreignKey.Columns {
+				if i >= len(referencedColumns) {
+					break
+				}
+				column, ok := table.findColumn(columnName)
+				referencedColumn, referencedOk := referencedTable.findColumn(referencedColumns[i])
+				if !ok || !referencedOk || normalizeType(column.Type) == normalizeType(referencedColumn.Type) {
+					continue
+				}
+
+				issues = append(issues, LintIssue{
+					Check:  LintInconsistentType,
+					Object: table.name + "." + column.Name,
+					Message: fmt.Sprintf("column %s.%s is %s but references %s.%s, which is %s",
+						table.name, column.Name, describeType(column.Type), referencedTable.name, referencedColumn.Name, describeType(referencedColumn.Type)),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func describeType(columnType string) string {
+	if strings.TrimSpace(columnType) == "" {
+		return "untyped"
+	}
+	return normalizeType(columnType)
+}
+
+var fullScanRegexp = regexp.MustCompile(`^SCAN (?:TABLE )?(\S+)(?: AS (\S+))?$`)
+
+// LintQuery runs the statement through EXPLAIN QUERY PLAN and reports the tables it reads entirely.
+// The suggested index is made of the columns the statement compares, equality comparisons first
+func (db *Db) LintQuery(statement string) ([]LintIssue, error) {
+	steps, err := db.GetQueryPlan(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := getStatementTokens(statement)
+	issues := make([]LintIssue, 0)
+	for _, step := range steps {
+		match := fullScanRegexp.FindStringSubmatch(step.Detail)
+		if match == nil {
+			continue
+		}
+
+		tableName, aliases := resolveTableAlias(tokens, match[1])
+		columns, err := db.DescribeTable("main", tableName)
+		if err != nil {
+			// subqueries, views and common table expressions are scanned too, but there is no table to index
+			continue
+		}
+
+		issue := LintIssue{
+			Check:   LintFullTableScan,
+			Object:  tableName,
+			Message: fmt.Sprintf("the statement scans every row of %s", tableName),
+		}
+		if indexColumns := getComparedColumns(tokens, aliases, columns); len(indexColumns) > 0 {
+			quotedColumns := make([]string, 0, len(indexColumns))
+			for _, column := range indexColumns {
+				quotedColumns = append(quotedColumns, QuoteIdentifier(column))
+			}
+			indexName := tableName + "_" + strings.Join(indexColumns, "_") + "_idx"
+			issue.Suggestion = fmt.Sprintf("CREATE INDEX %s ON %s (%s);", QuoteIdentifier(indexName), QuoteIdentifier(tableName), strings.Join(quotedColumns, ", "))
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+type statementToken struct {
+	tokenType int
+	text      string
+}
+
+func getStatementTokens(statement string) []statementToken {
+	lexer := sqliteparser.NewSQLiteLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+
+	tokens := make([]statementToken, 0)
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			return tokens
+		}
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		text := token.GetText()
+		if token.GetTokenType() == sqliteparser.SQLiteLexerIDENTIFIER {
+			text = unquoteIdentifier(text)
+		}
+		tokens = append(tokens, statementToken{tokenType: token.GetTokenType(), text: text})
+	}
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) < 2 {
+		return identifier
+	}
+	switch first, last := identifier[0], identifier[len(identifier)-1]; {
+	case first == '"' && last == '"':
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], `""`, `"`)
+	case first == '`' && last == '`':
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], "``", "`")
+	case first == '[' && last == ']':
+		return identifier[1 : len(identifier)-1]
+	}
+	return identifier
+}
+
+// resolveTableAlias returns the table behind a name of the query plan, which can be an alias, and all the names it has in the statement
+func resolveTableAlias(tokens []statementToken, name string) (tableName string, aliases map[string]bool) {
+	tableName = name
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].tokenType != sqliteparser.SQLiteLexerIDENTIFIER {
+			continue
+		}
+		aliasIndex := i + 1
+		if tokens[aliasIndex].tokenType == sqliteparser.SQLiteLexerAS_ && aliasIndex+1 < len(tokens) {
+			aliasIndex++
+		}
+		if tokens[aliasIndex].tokenType == sqliteparser.SQLiteLexerIDENTIFIER && strings.EqualFold(tokens[aliasIndex].text, name) {
+			tableName = tokens[i].text
+			break
+		}
+	}
+
+	aliases = map[string]bool{strings.ToLower(tableName): true, strings.ToLower(name): true}
+	return tableName, aliases
+}
+
+var equalityTokens = map[int]bool{
+	sqliteparser.SQLiteLexerASSIGN: true,
+	sqliteparser.SQLiteLexerEQ:     true,
+	sqliteparser.SQLiteLexerIN_:    true,
+	sqliteparser.SQLiteLexerIS_:    true,
+}
+
+var rangeTokens = map[int]bool{
+	sqliteparser.SQLiteLexerLT:       true,
+	sqliteparser.SQLiteLexerLT_EQ:    true,
+	sqliteparser.SQLiteLexerGT:       true,
+	sqliteparser.SQLiteLexerGT_EQ:    true,
+	sqliteparser.SQLiteLexerBETWEEN_: true,
+	sqliteparser.SQLiteLexerLIKE_:    true,
+	sqliteparser.SQLiteLexerGLOB_:    true,
+}
+
+// getComparedColumns returns the columns of the table compared in the statement, equality comparisons first and then
+// the first range comparison, which is the order in which an index can use them
+func getComparedColumns(tokens []statementToken, aliases map[string]bool, columns []ColumnDescription) []string {
+	equalityColumns := make([]string, 0)
+	rangeColumn := ""
+	seen := make(map[string]bool)
+
+	for i, token := range tokens {
+		if token.tokenType != sqliteparser.SQLiteLexerIDENTIFIER || i+1 >= len(tokens) {
+			continue
+		}
+		// a qualified column must belong to the scanned table
+		if i >= 2 && tokens[i-1].tokenType == sqliteparser.SQLiteLexerDOT && !aliases[strings.ToLower(tokens[i-2].text)] {
+			continue
+		}
+
+		var column string
+		for _, description := range columns {
+			if strings.EqualFold(description.Name, token.text) {
+				column = description.Name
+			}
+		}
+		if column == "" || seen[strings.ToLower(column)] {
+			continue
+		}
+
+		switch next := tokens[i+1].tokenType; {
+		case equalityTokens[next]:
+			equalityColumns = append(equalityColumns, column)
+			seen[strings.ToLower(column)] = true
+		case rangeTokens[next] && rangeColumn == "":
+			rangeColumn = column
+			seen[strings.ToLower(column)] = true
+		}
+	}
+
+	if rangeColumn != "" {
+		equalityColumns = append(equalityColumns, rangeColumn)
+	}
+	return equalityColumns
+}
@@ -0,0 +1,271 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+	"github.com/tursodatabase/libsql-client-go/sqliteparserutils"
+)
+
+// MigrationsTable records the version and checksum of every migration applied to the database
+const MigrationsTable = "libsql_shell_migrations"
+
+type Migration struct {
+	// Version is the file name without the .sql extension. Migrations are applied in lexicographic order of version
+	Version  string
+	Path     string
+	Checksum string
+	Sql      string
+}
+
+type MigrationState string
+
+const (
+	MigrationApplied  MigrationState = "applied"
+	MigrationPending  MigrationState = "pending"
+	MigrationModified MigrationState = "modified"
+	// MigrationMissing is a migration recorded in the database whose file no longer exists
+	MigrationMissing MigrationState = "missing"
+)
+
+type MigrationStatus struct {
+	Version   string
+	State     MigrationState
+	AppliedAt string
+}
+
+type MigrationModifiedError struct {
+	Version string
+}
+
+func (e *MigrationModifiedError) Error() string {
+	return fmt.Sprintf("migration %s was modified after being applied. Create a new migration instead of changing it", e.Version)
+}
+
+type MigrationFailedError struct {
+	Version string
+	Err     error
+}
+
+func (e *MigrationFailedError) Error() string {
+	return fmt.Sprintf("failed to apply migration %s: %v", e.Version, e.Err)
+}
+
+func (e *MigrationFailedError) Unwrap() error {
+	return e.Err
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt string
+}
+
+// ReadMigrations reads the *.sql files of the directory ordered by name
+func ReadMigrations(dir string) ([]Migration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	migrations := make([]Migration, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		checksum := sha256.Sum256(content)
+		migrations = append(migrations, Migration{
+			Version:  strings.TrimSuffix(filepath.Base(path), ".sql"),
+			Path:     path,
+			Checksum: hex.EncodeToString(checksum[:]),
+			Sql:      string(content),
+		})
+	}
+	return migrations, nil
+}
+
+// GetMigrationStatus compares the migrations with the ones recorded in the database, without creating the tracking table
+func (db *Db) GetMigrationStatus(migrations []Migration) ([]MigrationStatus, error) {
+	applied, err := db.getAppliedMigrations(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, State: MigrationPending}
+		if recorded, ok := applied[migration.Version]; ok {
+			status.State = MigrationApplied
+			if recorded.checksum != migration.Checksum {
+				status.State = MigrationModified
+			}
+			status.AppliedAt = recorded.appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, recorded := range applied {
+		statuses = append(statuses, MigrationStatus{Version: version, State: MigrationMissing, AppliedAt: recorded.appliedAt})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// GetPendingMigrations returns the migrations not applied yet. It fails if an applied migration was modified since
+func (db *Db) GetPendingMigrations(migrations []Migration) ([]Migration, error) {
+	statuses, err := db.GetMigrationStatus(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingVersions := make(map[string]bool)
+	for _, status := range statuses {
+		switch status.State {
+		case MigrationModified:
+			return nil, &MigrationModifiedError{Version: status.Version}
+		case MigrationPending:
+			pendingVersions[status.Version] = true
+		}
+	}
+
+	pending := make([]Migration, 0, len(pendingVersions))
+	for _, migration := range migrations {
+		if pendingVersions[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// SplitMigration returns the queries of the migration the way they are sent to the database
+func (db *Db) SplitMigration(migration Migration) []string {
+	queries := make([]string, 0)
+	for _, query := range db.prepareStatementsIntoQueries(migration.Sql) {
+		if strings.TrimSpace(query) != "" {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+// ApplyMigration runs the queries of the migration and records it in the tracking table, all in a single transaction.
+// Over HTTP, where each query is sent in its own request, the transaction is sent as one batch
+func (db *Db) ApplyMigration(migration Migration) error {
+	ctx := context.Background()
+
+	if err := checkMigrationHasNoTransaction(migration); err != nil {
+		return &MigrationFailedError{Version: migration.Version, Err: err}
+	}
+
+	if _, err := db.sqlDb.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+MigrationsTable+` (
+	version TEXT PRIMARY KEY,
+	checksum TEXT NOT NULL,
+	applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return err
+	}
+
+	if db.isHttp() {
+		statements, _ := sqliteparserutils.SplitStatement(migration.Sql)
+		statements = append(statements, fmt.Sprintf(`INSERT INTO %s (version, checksum) VALUES (%s, %s)`,
+			MigrationsTable, getSQLLiteral(migration.Version), getSQLLiteral(migration.Checksum)))
+		batch := "BEGIN;\n" + strings.Join(statements, ";\n") + ";\nCOMMIT;"
+		if _, err := db.sqlDb.ExecContext(ctx, batch); err != nil {
+			return &MigrationFailedError{Version: migration.Version, Err: treatDbError(err)}
+		}
+		return nil
+	}
+
+	tx, err := db.sqlDb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range db.SplitMigration(migration) {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return &MigrationFailedError{Version: migration.Version, Err: treatDbError(err)}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO `+MigrationsTable+` (version, checksum) VALUES (?, ?)`, migration.Version, migration.Checksum); err != nil {
+		return &MigrationFailedError{Version: migration.Version, Err: err}
+	}
+	return tx.Commit()
+}
+
+// checkMigrationHasNoTransaction fails on the statements that would end the transaction the migration is applied in
+func checkMigrationHasNoTransaction(migration Migration) error {
+	statements, _ := sqliteparserutils.SplitStatement(migration.Sql)
+	for _, statement := range statements {
+		tokens := getStatementTokens(statement)
+		if len(tokens) == 0 {
+			continue
+		}
+		switch tokens[0].tokenType {
+		case sqliteparser.SQLiteLexerBEGIN_, sqliteparser.SQLiteLexerCOMMIT_, sqliteparser.SQLiteLexerEND_:
+		case sqliteparser.SQLiteLexerROLLBACK_:
+			// ROLLBACK TO a savepoint keeps the transaction open
+			if hasToken(tokens, sqliteparser.SQLiteLexerTO_) {
+				continue
+			}
+		default:
+			continue
+		}
+		return fmt.Errorf("migrations are applied in a transaction of their own, remove the %s statement from %s",
+			strings.ToUpper(tokens[0].text), filepath.Base(migration.Path))
+	}
+	return nil
+}
+
+func hasToken(tokens []statementToken, tokenType int) bool {
+	for _, token := range tokens {
+		if token.tokenType == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *Db) getAppliedMigrations(ctx context.Context) (map[string]appliedMigration, error) {
+	applied := make(map[string]appliedMigration)
+
+	var tableCount int
+	if err := db.sqlDb.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_schema WHERE type = 'table' AND name = ?`, MigrationsTable).Scan(&tableCount); err != nil {
+		return nil, err
+	}
+	if tableCount == 0 {
+		return applied, nil
+	}
+
+	rows, err := db.sqlDb.QueryContext(ctx, `SELECT version, checksum, applied_at FROM `+MigrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version string
+		var recorded appliedMigration
+		if err := rows.Scan(&version, &recorded.checksum, &recorded.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = recorded
+	}
+	return applied, rows.Err()
+}
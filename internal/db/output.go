@@ -62,31 +62,66 @@ func (c CSVPrinter) print(statementResult StatementResult, outF io.Writer) error
 type JSONPrinter struct{}
 
 func (c JSONPrinter) print(statementResult StatementResult, outF io.Writer) error {
+	data, err := getJSONRows(statementResult)
+	if err != nil {
+		return err
+	}
+
+	json, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if string(json) != "null" {
+		fmt.Fprintln(outF, string(json))
+	}
+	return nil
+}
+
+// EchoJSONPrinter prints the rows of a statement grouped with the statement that produced them
+type EchoJSONPrinter struct {
+	statement string
+}
+
+type echoedStatementResult struct {
+	Statement string                   `json:"statement"`
+	Rows      []map[string]interface{} `json:"rows"`
+}
+
+func (c EchoJSONPrinter) print(statementResult StatementResult, outF io.Writer) error {
+	data, err := getJSONRows(statementResult)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = []map[string]interface{}{}
+	}
+
+	json, err := json.Marshal(echoedStatementResult{Statement: c.statement, Rows: data})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(outF, string(json))
+	return nil
+}
+
+func getJSONRows(statementResult StatementResult) ([]map[string]interface{}, error) {
 	var data []map[string]interface{}
 
 	for row := range statementResult.RowCh {
 		if row.Err != nil {
-			return row.Err
+			return nil, row.Err
 		}
 		rowData := make(map[string]interface{})
 		formattedRow, err := FormatData(row.Row, JSON)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for i, v := range statementResult.ColumnNames {
 			rowData[v] = formattedRow[i]
 		}
 		data = append(data, rowData)
 	}
-
-	json, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	if string(json) != "null" {
-		fmt.Fprintln(outF, string(json))
-	}
-	return nil
+	return data, nil
 }
 
 func appendData(statementResult StatementResult, data [][]string, mode FormatType) ([][]string, error) {
@@ -156,6 +191,17 @@ func PrintStatementResult(statementResult StatementResult, outF io.Writer, witho
 	return nil
 }
 
+// PrintRows prints rows built by the shell itself, instead of read from the database, with the printer of the given mode
+func PrintRows(columnNames []string, rows [][]interface{}, outF io.Writer, withoutHeader bool, mode enums.PrintMode) error {
+	rowCh := make(chan rowResult, len(rows))
+	for _, row := range rows {
+		rowCh <- *newRowResult(row)
+	}
+	close(rowCh)
+
+	return PrintStatementResult(*newStatementResult(columnNames, rowCh), outF, withoutHeader, mode)
+}
+
 func PrintError(err error, errF io.Writer) {
 	fmt.Fprintf(errF, "Error: %s\n", err.Error())
 }
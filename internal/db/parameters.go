@@ -0,0 +1,338 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+	"github.com/tursodatabase/libsql-client-go/sqliteparserutils"
+)
+
+// Parameters holds the values bound to statement parameters, by name as written in statements: ":id", "@id", "$id" or
+// "?1" for positional ones
+type Parameters map[string]interface{}
+
+// NormalizeParameterName turns a bare number into the name of the positional parameter, so "1" and "?1" are the same
+func NormalizeParameterName(name string) (string, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return "?" + name, nil
+	}
+	if len(name) < 2 || !strings.ContainsRune("?:@$", rune(name[0])) {
+		return "", fmt.Errorf("invalid parameter name %s. Use ?NNN, :name, @name or $name", name)
+	}
+	if name[0] == '?' {
+		if _, err := strconv.Atoi(name[1:]); err != nil {
+			return "", fmt.Errorf("invalid parameter name %s. Use ?NNN, :name, @name or $name", name)
+		}
+	}
+	return name, nil
+}
+
+// ParseParameterValue reads a value the way it would be written in SQL: integers, reals, NULL and quoted strings.
+// Anything else is taken as text, so quotes can be omitted
+func ParseParameterValue(value string) interface{} {
+	if strings.EqualFold(value, "NULL") {
+		return nil
+	}
+	if integer, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return integer
+	}
+	// nan, inf and infinity are parsed as floats too, but SQLite has no literal for them
+	if real, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(real, 0) && !math.IsNaN(real) {
+		return real
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
+
+// SortedNames returns the parameter names in alphabetical order
+func (p Parameters) SortedNames() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type bindParameter struct {
+	name  string
+	index int
+}
+
+// bindParameterIndexer assigns to parameters the index SQLite does: "?NNN" uses NNN, while "?" and the first
+// occurrence of a name use the largest index so far plus one
+type bindParameterIndexer struct {
+	indexByName map[string]int
+	maxIndex    int
+}
+
+func newBindParameterIndexer() *bindParameterIndexer {
+	return &bindParameterIndexer{indexByName: make(map[string]int)}
+}
+
+func (b *bindParameterIndexer) next(text string) bindParameter {
+	name := text
+	index, seen := b.indexByName[name]
+	switch {
+	case seen:
+	case name == "?":
+		index = b.maxIndex + 1
+		name = "?" + strconv.Itoa(index)
+	case name[0] == '?':
+		index, _ = strconv.Atoi(name[1:])
+		name = "?" + strconv.Itoa(index)
+	default:
+		index = b.maxIndex + 1
+	}
+	b.indexByName[name] = index
+	if index > b.maxIndex {
+		b.maxIndex = index
+	}
+	return bindParameter{name: name, index: index}
+}
+
+func getBindParameters(statement string) []bindParameter {
+	lexer := sqliteparser.NewSQLiteLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+
+	parameters := make([]bindParameter, 0)
+	indexer := newBindParameterIndexer()
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			return parameters
+		}
+		if token.GetTokenType() == sqliteparser.SQLiteLexerBIND_PARAMETER {
+			parameters = append(parameters, indexer.next(token.GetText()))
+		}
+	}
+}
+
+func hasBindParameters(statement string) bool {
+	return len(getBindParameters(statement)) > 0
+}
+
+// getParameterArgs returns the values of the parameters of the statement ordered by index. Named parameters have an
+// index too, so both drivers can bind every parameter positionally, while named arguments aren't supported by every
+// libsql protocol. Parameters without a value are bound to NULL, as SQLite does
+func getParameterArgs(statement string, parameters Parameters) []interface{} {
+	bindParameters := getBindParameters(statement)
+	if len(bindParameters) == 0 {
+		return nil
+	}
+
+	maxIndex := 0
+	for _, parameter := range bindParameters {
+		if parameter.index > maxIndex {
+			maxIndex = parameter.index
+		}
+	}
+
+	args := make([]interface{}, maxIndex)
+	for _, parameter := range bindParameters {
+		args[parameter.index-1] = parameters[parameter.name]
+	}
+	return args
+}
+
+// ParameterHint describes a parameter of a statement without a value
+type ParameterHint struct {
+	Name string
+	// Column and Type are the name and declared type of the column the parameter is compared with or inserted into,
+	// when they can be inferred from the statement
+	Column string
+	Type   string
+}
+
+var comparisonTokenTypes = map[int]bool{
+	sqliteparser.SQLiteLexerASSIGN:  true,
+	sqliteparser.SQLiteLexerEQ:      true,
+	sqliteparser.SQLiteLexerNOT_EQ1: true,
+	sqliteparser.SQLiteLexerNOT_EQ2: true,
+	sqliteparser.SQLiteLexerLT:      true,
+	sqliteparser.SQLiteLexerLT_EQ:   true,
+	sqliteparser.SQLiteLexerGT:      true,
+	sqliteparser.SQLiteLexerGT_EQ:   true,
+	sqliteparser.SQLiteLexerLIKE_:   true,
+	sqliteparser.SQLiteLexerGLOB_:   true,
+	sqliteparser.SQLiteLexerIS_:     true,
+}
+
+// GetUnboundParameters returns the parameters of the statements without a value, in order of appearance. A parameter
+// used in several places gets a single value, so its column is only hinted when every place agrees on it
+func (db *Db) GetUnboundParameters(statements string) []ParameterHint {
+	hints := make([]ParameterHint, 0)
+	positions := make(map[string]int)
+
+	// the index of positional parameters starts over on each statement
+	splitStatements, _ := sqliteparserutils.SplitStatement(statements)
+	for _, statement := range splitStatements {
+		for _, hint := range db.getUnboundParameters(statement) {
+			position, ok := positions[hint.Name]
+			if !ok {
+				positions[hint.Name] = len(hints)
+				hints = append(hints, hint)
+				continue
+			}
+			if hints[position] != hint {
+				hints[position] = ParameterHint{Name: hint.Name}
+			}
+		}
+	}
+	return hints
+}
+
+// getUnboundParameters returns a hint for each place of the statement that uses a parameter without a value
+func (db *Db) getUnboundParameters(statement string) []ParameterHint {
+	tokens := getStatementTokens(statement)
+	tables := getStatementTables(tokens)
+	insertColumns := getInsertColumnsByValuePosition(db, tokens, tables)
+
+	hints := make([]ParameterHint, 0)
+	indexer := newBindParameterIndexer()
+	for i, token := range tokens {
+		if token.tokenType != sqliteparser.SQLiteLexerBIND_PARAMETER {
+			continue
+		}
+		parameter := indexer.next(token.text)
+		if _, ok := db.parameters[parameter.name]; ok {
+			continue
+		}
+
+		hint := ParameterHint{Name: parameter.name, Column: insertColumns[i]}
+		if hint.Column == "" {
+			hint.Column = getComparedColumn(tokens, i)
+		}
+		if hint.Column != "" {
+			hint.Type = db.getColumnType(tables, hint.Column)
+		}
+		hints = append(hints, hint)
+	}
+	return hints
+}
+
+// getComparedColumn returns the column in "column = ?" or "? = column" expressions
+func getComparedColumn(tokens []statementToken, parameterPosition int) string {
+	if parameterPosition >= 2 && comparisonTokenTypes[tokens[parameterPosition-1].tokenType] &&
+		tokens[parameterPosition-2].tokenType == sqliteparser.SQLiteLexerIDENTIFIER {
+		return tokens[parameterPosition-2].text
+	}
+	if parameterPosition+2 < len(tokens) && comparisonTokenTypes[tokens[parameterPosition+1].tokenType] &&
+		tokens[parameterPosition+2].tokenType == sqliteparser.SQLiteLexerIDENTIFIER &&
+		(parameterPosition+3 == len(tokens) || tokens[parameterPosition+3].tokenType != sqliteparser.SQLiteLexerDOT) {
+		return tokens[parameterPosition+2].text
+	}
+	return ""
+}
+
+// getStatementTables returns the tables following FROM, JOIN, UPDATE and INTO
+func getStatementTables(tokens []statementToken) []string {
+	tables := make([]string, 0)
+	for i := 0; i+1 < len(tokens); i++ {
+		switch tokens[i].tokenType {
+		case sqliteparser.SQLiteLexerFROM_, sqliteparser.SQLiteLexerJOIN_, sqliteparser.SQLiteLexerUPDATE_, sqliteparser.SQLiteLexerINTO_:
+		default:
+			continue
+		}
+		position := i + 1
+		// skip the schema of schema.table
+		if position+2 < len(tokens) && tokens[position+1].tokenType == sqliteparser.SQLiteLexerDOT {
+			position += 2
+		}
+		if tokens[position].tokenType == sqliteparser.SQLiteLexerIDENTIFIER {
+			tables = append(tables, tokens[position].text)
+		}
+	}
+	return tables
+}
+
+// getInsertColumnsByValuePosition maps the position of each parameter of "INSERT INTO table (columns) VALUES (...)" to
+// its column, when the parameter is a whole value. Without a column list, the columns of the table are used
+func getInsertColumnsByValuePosition(db *Db, tokens []statementToken, tables []string) map[int]string {
+	columnsByPosition := make(map[int]string)
+
+	position := 0
+	for position < len(tokens) && tokens[position].tokenType != sqliteparser.SQLiteLexerINTO_ {
+		position++
+	}
+	if position == len(tokens) || len(tables) == 0 {
+		return columnsByPosition
+	}
+
+	columns := make([]string, 0)
+	for position < len(tokens) && tokens[position].tokenType != sqliteparser.SQLiteLexerOPEN_PAR && tokens[position].tokenType != sqliteparser.SQLiteLexerVALUES_ {
+		position++
+	}
+	if position < len(tokens) && tokens[position].tokenType == sqliteparser.SQLiteLexerOPEN_PAR {
+		for position++; position < len(tokens) && tokens[position].tokenType != sqliteparser.SQLiteLexerCLOSE_PAR; position++ {
+			if tokens[position].tokenType != sqliteparser.SQLiteLexerCOMMA {
+				columns = append(columns, tokens[position].text)
+			}
+		}
+	} else if descriptions, err := db.DescribeTable("main", tables[0]); err == nil {
+		for _, description := range descriptions {
+			if description.Kind == NormalColumn {
+				columns = append(columns, description.Name)
+			}
+		}
+	}
+
+	for position < len(tokens) && tokens[position].tokenType != sqliteparser.SQLiteLexerVALUES_ {
+		position++
+	}
+
+	// each row of VALUES starts over from the first column
+	depth, column, valueTokens := 0, 0, 0
+	for position++; position < len(tokens); position++ {
+		token := tokens[position]
+		switch {
+		case token.tokenType == sqliteparser.SQLiteLexerOPEN_PAR:
+			depth++
+			if depth == 1 {
+				column, valueTokens = 0, 0
+				continue
+			}
+		case token.tokenType == sqliteparser.SQLiteLexerCLOSE_PAR:
+			depth--
+		case token.tokenType == sqliteparser.SQLiteLexerCOMMA && depth == 1:
+			column, valueTokens = column+1, 0
+			continue
+		}
+		if depth == 0 {
+			if token.tokenType != sqliteparser.SQLiteLexerCOMMA && token.tokenType != sqliteparser.SQLiteLexerCLOSE_PAR {
+				return columnsByPosition
+			}
+			continue
+		}
+
+		valueTokens++
+		isWholeValue := valueTokens == 1 && position+1 < len(tokens) &&
+			(tokens[position+1].tokenType == sqliteparser.SQLiteLexerCOMMA || tokens[position+1].tokenType == sqliteparser.SQLiteLexerCLOSE_PAR)
+		if token.tokenType == sqliteparser.SQLiteLexerBIND_PARAMETER && isWholeValue && column < len(columns) {
+			columnsByPosition[position] = columns[column]
+		}
+	}
+	return columnsByPosition
+}
+
+func (db *Db) getColumnType(tables []string, column string) string {
+	for _, table := range tables {
+		descriptions, err := db.DescribeTable("main", table)
+		if err != nil {
+			continue
+		}
+		for _, description := range descriptions {
+			if strings.EqualFold(description.Name, column) {
+				return description.Type
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+func TestParseParameterValue_GivenSQLLiterals_ExpectValuesOfTheirTypes(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(db.ParseParameterValue("42"), qt.Equals, int64(42))
+	c.Assert(db.ParseParameterValue("-1.5"), qt.Equals, -1.5)
+	c.Assert(db.ParseParameterValue("null"), qt.IsNil)
+	c.Assert(db.ParseParameterValue("'it''s'"), qt.Equals, "it's")
+	c.Assert(db.ParseParameterValue("'42'"), qt.Equals, "42")
+	c.Assert(db.ParseParameterValue("unquoted text"), qt.Equals, "unquoted text")
+	c.Assert(db.ParseParameterValue("nan"), qt.Equals, "nan")
+	c.Assert(db.ParseParameterValue("Infinity"), qt.Equals, "Infinity")
+	c.Assert(db.ParseParameterValue("-inf"), qt.Equals, "-inf")
+}
+
+func TestNormalizeParameterName_GivenPositionalAndNamedParameters_ExpectNamesAsWrittenInStatements(t *testing.T) {
+	c := qt.New(t)
+
+	for name, expected := range map[string]string{"1": "?1", "?2": "?2", ":id": ":id", "@id": "@id", "$id": "$id"} {
+		normalized, err := db.NormalizeParameterName(name)
+		c.Assert(err, qt.IsNil)
+		c.Assert(normalized, qt.Equals, expected)
+	}
+
+	for _, name := range []string{"id", "?id", ":", ""} {
+		_, err := db.NormalizeParameterName(name)
+		c.Assert(err, qt.IsNotNil)
+	}
+}
+
+func TestGetUnboundParameters_GivenComparisonsAndInserts_ExpectTypesOfTheirColumns(t *testing.T) {
+	c := qt.New(t)
+
+	database, err := db.NewDb(filepath.Join(c.TempDir(), "test.sqlite"), "", "", false, "")
+	c.Assert(err, qt.IsNil)
+	defer database.Close()
+	_, err = database.ExecuteStatementsDiscardingRows("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, score REAL);")
+	c.Assert(err, qt.IsNil)
+
+	database.SetParameters(db.Parameters{":bound": 1})
+
+	c.Assert(database.GetUnboundParameters("SELECT * FROM users u WHERE u.id = :id AND :name = name AND score > :bound;"), qt.DeepEquals, []db.ParameterHint{
+		{Name: ":id", Column: "id", Type: "INTEGER"},
+		{Name: ":name", Column: "name", Type: "TEXT"},
+	})
+	c.Assert(database.GetUnboundParameters("INSERT INTO users (score, name) VALUES (?, abs(?)); INSERT INTO users VALUES (?, ?, ?);"), qt.DeepEquals, []db.ParameterHint{
+		{Name: "?1"},
+		{Name: "?2"},
+		{Name: "?3", Column: "score", Type: "REAL"},
+	})
+	c.Assert(database.GetUnboundParameters("SELECT * FROM users WHERE id = :id; DELETE FROM users WHERE id = :id;"), qt.DeepEquals, []db.ParameterHint{
+		{Name: ":id", Column: "id", Type: "INTEGER"},
+	})
+}
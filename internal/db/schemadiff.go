@@ -0,0 +1,326 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+)
+
+// DiffSchema returns the statements that migrate the schema of db into the schema of target.
+// Tables are changed with ALTER TABLE ADD COLUMN when possible, and rebuilt otherwise like
+// https://www.sqlite.org/lang_altertable.html#otheralter describes. Renamed objects are dropped and created again
+func (db *Db) DiffSchema(target *Db) ([]string, error) {
+	ctx := context.Background()
+
+	currentObjects, err := getDiffableObjects(ctx, db.sqlDb)
+	if err != nil {
+		return nil, err
+	}
+	targetObjects, err := getDiffableObjects(ctx, target.sqlDb)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := schemaDiff{current: indexSchemaObjects(currentObjects), target: indexSchemaObjects(targetObjects)}
+
+	droppedTables := make(map[string]bool)
+	for _, object := range currentObjects {
+		if _, ok := diff.target[diffKey(object)]; !ok && object.objectType == "table" {
+			droppedTables[strings.ToLower(object.name)] = true
+		}
+	}
+
+	// tables are compared first, since the objects of rebuilt tables must be created again
+	rebuiltTables := make(map[string]bool)
+	tableStatements := make([]string, 0)
+	for _, object := range currentObjects {
+		if object.objectType == "table" && droppedTables[strings.ToLower(object.name)] {
+			tableStatements = append(tableStatements, fmt.Sprintf("DROP TABLE %s;", QuoteIdentifier(object.name)))
+		}
+	}
+	for _, object := range targetObjects {
+		if object.objectType != "table" {
+			continue
+		}
+		current, ok := diff.current[diffKey(object)]
+		if !ok {
+			tableStatements = append(tableStatements, object.sql+";")
+			continue
+		}
+		if normalizeSQL(current.sql) == normalizeSQL(object.sql) {
+			continue
+		}
+
+		// virtual tables keep their content in shadow tables, so they can only be created again
+		if isVirtualTable(current) || isVirtualTable(object) {
+			tableStatements = append(tableStatements, fmt.Sprintf("DROP TABLE %s;", QuoteIdentifier(current.name)), object.sql+";")
+			continue
+		}
+
+		if addColumns, ok := getAddColumnStatements(current, object); ok {
+			tableStatements = append(tableStatements, addColumns...)
+			continue
+		}
+
+		rebuild, err := getRebuildStatements(ctx, db, target, current, object)
+		if err != nil {
+			return nil, err
+		}
+		tableStatements = append(tableStatements, rebuild...)
+		rebuiltTables[strings.ToLower(object.name)] = true
+	}
+
+	// views can reference any table, so they are all created again when a table is rebuilt
+	mustRecreate := func(object schemaObject) bool {
+		switch object.objectType {
+		case "view":
+			return len(rebuiltTables) > 0
+		default:
+			return rebuiltTables[strings.ToLower(object.tableName)]
+		}
+	}
+
+	statements := make([]string, 0)
+	for _, objectType := range []string{"trigger", "view", "index"} {
+		for _, object := range currentObjects {
+			if object.objectType != objectType || droppedTables[strings.ToLower(object.tableName)] || rebuiltTables[strings.ToLower(object.tableName)] {
+				continue
+			}
+			target, ok := diff.target[diffKey(object)]
+			if !ok || normalizeSQL(target.sql) != normalizeSQL(object.sql) || mustRecreate(object) {
+				statements = append(statements, fmt.Sprintf("DROP %s IF EXISTS %s;", strings.ToUpper(objectType), QuoteIdentifier(object.name)))
+			}
+		}
+	}
+
+	statements = append(statements, tableStatements...)
+
+	for _, objectType := range []string{"index", "view", "trigger"} {
+		for _, object := range targetObjects {
+			if object.objectType != objectType {
+				continue
+			}
+			current, ok := diff.current[diffKey(object)]
+			if !ok || normalizeSQL(current.sql) != normalizeSQL(object.sql) || mustRecreate(object) {
+				statements = append(statements, object.sql+";")
+			}
+		}
+	}
+
+	if len(statements) == 0 {
+		return statements, nil
+	}
+
+	if len(rebuiltTables) == 0 {
+		script := append([]string{"BEGIN TRANSACTION;"}, statements...)
+		return append(script, "COMMIT;"), nil
+	}
+
+	// foreign keys can't be switched off inside a transaction, and are only switched on again when they were on before
+	var foreignKeys bool
+	if err := db.sqlDb.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return nil, err
+	}
+	script := append([]string{"PRAGMA foreign_keys=OFF;", "BEGIN TRANSACTION;"}, statements...)
+	script = append(script, "PRAGMA foreign_key_check;", "COMMIT;")
+	if foreignKeys {
+		script = append(script, "PRAGMA foreign_keys=ON;")
+	}
+	return script, nil
+}
+
+type schemaDiff struct {
+	current map[string]schemaObject
+	target  map[string]schemaObject
+}
+
+func diffKey(object schemaObject) string {
+	return object.objectType + ":" + strings.ToLower(object.name)
+}
+
+func indexSchemaObjects(objects []schemaObject) map[string]schemaObject {
+	indexed := make(map[string]schemaObject, len(objects))
+	for _, object := range objects {
+		indexed[diffKey(object)] = object
+	}
+	return indexed
+}
+
+// getDiffableObjects skips the shadow tables of virtual tables, which are created along with them
+func getDiffableObjects(ctx context.Context, source queryer) ([]schemaObject, error) {
+	objects, err := getSchemaObjects(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return withoutShadowTables(objects, getShadowTables(ctx, source)), nil
+}
+
+// normalizeSQL ignores the differences of whitespace, quoting and case that don't change the meaning of a statement
+func normalizeSQL(statement string) string {
+	tokens := getStatementTokens(statement)
+	normalized := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		switch token.tokenType {
+		case sqliteparser.SQLiteLexerIDENTIFIER:
+			normalized = append(normalized, strings.ToLower(token.text))
+		case sqliteparser.SQLiteLexerSTRING_LITERAL, sqliteparser.SQLiteLexerBLOB_LITERAL:
+			normalized = append(normalized, token.text)
+		default:
+			normalized = append(normalized, strings.ToUpper(token.text))
+		}
+	}
+	return strings.Join(normalized, " ")
+}
+
+type parsedCreateTable struct {
+	// nameStart and nameStop are the positions of the table name in the statement
+	nameStart int
+	nameStop  int
+	// columns has the original text of each column definition
+	columns []string
+	// definition is the normalized statement without its columns, to tell if anything else changed
+	definition string
+}
+
+type parserErrorListener struct {
+	*antlr.DefaultErrorListener
+	failed bool
+}
+
+func (l *parserErrorListener) SyntaxError(antlr.Recognizer, interface{}, int, int, string, antlr.RecognitionException) {
+	l.failed = true
+}
+
+func parseCreateTable(statement string) (*parsedCreateTable, bool) {
+	input := antlr.NewInputStream(statement)
+	lexer := sqliteparser.NewSQLiteLexer(input)
+	lexer.RemoveErrorListeners()
+	parser := sqliteparser.NewSQLiteParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	parser.RemoveErrorListeners()
+	errorListener := &parserErrorListener{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+	parser.AddErrorListener(errorListener)
+
+	createTable := parser.Create_table_stmt()
+	columnDefinitions := createTable.AllColumn_def()
+	if errorListener.failed || len(columnDefinitions) == 0 || createTable.Table_name() == nil {
+		return nil, false
+	}
+
+	parsed := &parsedCreateTable{
+		nameStart: createTable.Table_name().GetStart().GetStart(),
+		nameStop:  createTable.Table_name().GetStop().GetStop(),
+	}
+	for _, columnDefinition := range columnDefinitions {
+		parsed.columns = append(parsed.columns, input.GetText(columnDefinition.GetStart().GetStart(), columnDefinition.GetStop().GetStop()))
+	}
+
+	// everything around the columns: the table name, the table constraints and the table options
+	definition := []string{normalizeSQL(statement[:columnDefinitions[0].GetStart().GetStart()])}
+	for _, constraint := range createTable.AllTable_constraint() {
+		definition = append(definition, normalizeSQL(input.GetText(constraint.GetStart().GetStart(), constraint.GetStop().GetStop())))
+	}
+	if closeParenthesis := createTable.CLOSE_PAR(); closeParenthesis != nil {
+		definition = append(definition, normalizeSQL(statement[closeParenthesis.GetSymbol().GetStart():]))
+	}
+	parsed.definition = strings.Join(definition, ", ")
+
+	return parsed, true
+}
+
+// getAddColumnStatements returns the ALTER TABLE ADD COLUMN statements that migrate the current table into the target one,
+// if the only change is new columns at the end that SQLite can add to an existing table
+func getAddColumnStatements(current schemaObject, target schemaObject) ([]string, bool) {
+	currentTable, ok := parseCreateTable(current.sql)
+	if !ok {
+		return nil, false
+	}
+	targetTable, ok := parseCreateTable(target.sql)
+	if !ok || currentTable.definition != targetTable.definition || len(targetTable.columns) <= len(currentTable.columns) {
+		return nil, false
+	}
+
+	for i, column := range currentTable.columns {
+		if normalizeSQL(column) != normalizeSQL(targetTable.columns[i]) {
+			return nil, false
+		}
+	}
+
+	statements := make([]string, 0)
+	for _, column := range targetTable.columns[len(currentTable.columns):] {
+		if !canAddColumn(column) {
+			return nil, false
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", QuoteIdentifier(target.name), column))
+	}
+	return statements, true
+}
+
+// canAddColumn follows the restrictions of https://www.sqlite.org/lang_altertable.html#altertabaddcol
+func canAddColumn(columnDefinition string) bool {
+	tokens := getStatementTokens(columnDefinition)
+	notNull, hasDefault := false, false
+	for i, token := range tokens {
+		switch token.tokenType {
+		case sqliteparser.SQLiteLexerPRIMARY_, sqliteparser.SQLiteLexerUNIQUE_, sqliteparser.SQLiteLexerSTORED_, sqliteparser.SQLiteLexerREFERENCES_:
+			return false
+		case sqliteparser.SQLiteLexerNOT_:
+			notNull = notNull || i+1 < len(tokens) && tokens[i+1].tokenType == sqliteparser.SQLiteLexerNULL_
+		case sqliteparser.SQLiteLexerDEFAULT_:
+			hasDefault = true
+			// the default value must be a constant
+			if i+1 < len(tokens) {
+				switch tokens[i+1].tokenType {
+				case sqliteparser.SQLiteLexerOPEN_PAR, sqliteparser.SQLiteLexerCURRENT_TIME_, sqliteparser.SQLiteLexerCURRENT_DATE_, sqliteparser.SQLiteLexerCURRENT_TIMESTAMP_:
+					return false
+				}
+			}
+		}
+	}
+	return !notNull || hasDefault
+}
+
+// getRebuildStatements creates the target table under a temporary name, copies the records of the columns both tables
+// have, and replaces the current table with it
+func getRebuildStatements(ctx context.Context, current *Db, target *Db, currentTable schemaObject, targetTable schemaObject) ([]string, error) {
+	parsed, ok := parseCreateTable(targetTable.sql)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse the definition of table %s", targetTable.name)
+	}
+	temporaryName := "new_" + targetTable.name
+	createTemporary := targetTable.sql[:parsed.nameStart] + QuoteIdentifier(temporaryName) + targetTable.sql[parsed.nameStop+1:]
+
+	currentColumns, err := getInsertableColumns(ctx, current.sqlDb, currentTable.name)
+	if err != nil {
+		return nil, err
+	}
+	targetColumns, err := getInsertableColumns(ctx, target.sqlDb, targetTable.name)
+	if err != nil {
+		return nil, err
+	}
+
+	currentColumnSet := make(map[string]bool, len(currentColumns))
+	for _, column := range currentColumns {
+		currentColumnSet[strings.ToLower(column)] = true
+	}
+	commonColumns := make([]string, 0)
+	for _, column := range targetColumns {
+		if currentColumnSet[strings.ToLower(column)] {
+			commonColumns = append(commonColumns, column)
+		}
+	}
+
+	statements := []string{createTemporary + ";"}
+	if len(commonColumns) > 0 {
+		columnList := strings.Join(commonColumns, ", ")
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;",
+			QuoteIdentifier(temporaryName), columnList, columnList, QuoteIdentifier(currentTable.name)))
+	}
+	statements = append(statements,
+		fmt.Sprintf("DROP TABLE %s;", QuoteIdentifier(currentTable.name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", QuoteIdentifier(temporaryName), QuoteIdentifier(targetTable.name)),
+	)
+	return statements, nil
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+	"github.com/tursodatabase/libsql-client-go/sqliteparserutils"
+)
+
+type ScannedStatement struct {
+	Text string
+	Line int
+}
+
+// StatementScanner reads SQL statements from a reader one at a time, so the input never has to be fully loaded in memory
+type StatementScanner struct {
+	reader *bufio.Reader
+
+	pending          strings.Builder
+	pendingStartLine int
+	linesRead        int
+	end              StatementEndTracker
+
+	scanned []ScannedStatement
+	current ScannedStatement
+	err     error
+	eof     bool
+}
+
+func NewStatementScanner(reader io.Reader) *StatementScanner {
+	return &StatementScanner{reader: bufio.NewReader(reader)}
+}
+
+// Scan advances to the next statement, which is then available through Statement.
+// It returns false when the input ends or an error happens
+func (s *StatementScanner) Scan() bool {
+	for len(s.scanned) == 0 {
+		if s.eof || s.err != nil {
+			return false
+		}
+		s.readLine()
+	}
+
+	s.current = s.scanned[0]
+	s.scanned = s.scanned[1:]
+	return true
+}
+
+func (s *StatementScanner) Statement() ScannedStatement {
+	return s.current
+}
+
+func (s *StatementScanner) Err() error {
+	return s.err
+}
+
+func (s *StatementScanner) readLine() {
+	line, err := s.reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		s.err = err
+		return
+	}
+	s.eof = err == io.EOF
+
+	if line != "" {
+		s.linesRead++
+		if s.pending.Len() == 0 {
+			s.pendingStartLine = s.linesRead
+		}
+		s.pending.WriteString(line)
+	}
+
+	if mayFinish := s.end.ReadLine(line); s.eof || mayFinish {
+		s.splitPending()
+	}
+}
+
+func (s *StatementScanner) splitPending() {
+	pending := s.pending.String()
+	statements, extraInfo := sqliteparserutils.SplitStatement(pending)
+
+	finished := !extraInfo.IncompleteCreateTriggerStatement &&
+		!extraInfo.IncompleteMultilineComment &&
+		extraInfo.LastTokenType == sqliteparser.SQLiteLexerSCOL
+	if !finished && !s.eof {
+		return
+	}
+
+	s.scanned = append(s.scanned, locateStatements(pending, statements, s.pendingStartLine)...)
+	s.pending.Reset()
+	s.end.Reset()
+}
+
+// SplitStatementsWithLines splits the text into statements along with the line each one starts at, the text starting
+// at firstLine
+func SplitStatementsWithLines(text string, firstLine int) []ScannedStatement {
+	statements, _ := sqliteparserutils.SplitStatement(text)
+	return locateStatements(text, statements, firstLine)
+}
+
+func locateStatements(text string, statements []string, firstLine int) []ScannedStatement {
+	located := make([]ScannedStatement, 0, len(statements))
+	offset := 0
+	line := firstLine
+	for _, statement := range statements {
+		if position := strings.Index(text[offset:], statement); position >= 0 {
+			line += strings.Count(text[offset:offset+position], "\n")
+			offset += position
+		}
+		located = append(located, ScannedStatement{Text: statement, Line: line})
+	}
+	return located
+}
+
+// StatementEndTracker follows the string literals, quoted identifiers and comments of SQL text read one line at a time.
+// The lexer gives up on unterminated quoted text and would take a semicolon inside it for the end of a statement, and
+// tokenizing the whole text again for every line is slow, so it tells which lines may finish a statement
+type StatementEndTracker struct {
+	closingQuote       byte
+	insideBlockComment bool
+	// endsWithSemicolon tells if the last character outside quoted text and comments is a semicolon
+	endsWithSemicolon bool
+}
+
+// ReadLine follows the next line of the text and reports if the text may end with a finished statement now. The text
+// still has to be tokenized to be sure, since a semicolon can also end a statement in the body of a CREATE TRIGGER
+func (t *StatementEndTracker) ReadLine(line string) (mayFinish bool) {
+	insideLineComment := false
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		switch {
+		case t.closingQuote != 0:
+			if char == t.closingQuote {
+				t.closingQuote = 0
+			}
+		case insideLineComment:
+			insideLineComment = char != '\n'
+		case t.insideBlockComment:
+			if char == '*' && i+1 < len(line) && line[i+1] == '/' {
+				t.insideBlockComment = false
+				i++
+			}
+		case char == '-' && i+1 < len(line) && line[i+1] == '-':
+			insideLineComment = true
+			i++
+		case char == '/' && i+1 < len(line) && line[i+1] == '*':
+			t.insideBlockComment = true
+			i++
+		case char == '\'' || char == '"' || char == '`':
+			t.closingQuote = char
+			t.endsWithSemicolon = false
+		case char == '[':
+			t.closingQuote = ']'
+			t.endsWithSemicolon = false
+		case !unicode.IsSpace(rune(char)):
+			t.endsWithSemicolon = char == ';'
+		}
+	}
+
+	return strings.TrimSpace(line) != "" && t.closingQuote == 0 && !t.insideBlockComment && t.endsWithSemicolon
+}
+
+// Reset forgets the text read so far
+func (t *StatementEndTracker) Reset() {
+	*t = StatementEndTracker{}
+}
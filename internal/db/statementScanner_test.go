@@ -0,0 +1,87 @@
+package db_test
+
+import (
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+func scanAllStatements(c *qt.C, input string) []db.ScannedStatement {
+	scanner := db.NewStatementScanner(strings.NewReader(input))
+	statements := make([]db.ScannedStatement, 0)
+	for scanner.Scan() {
+		statements = append(statements, scanner.Statement())
+	}
+	c.Assert(scanner.Err(), qt.IsNil)
+	return statements
+}
+
+func TestStatementScanner_GivenStatementsInMultipleLines_ExpectStatementsWithTheirStartLine(t *testing.T) {
+	c := qt.New(t)
+
+	input := "SELECT 1;\n\n-- comment\nSELECT\n  2; SELECT 3;\nSELECT 4"
+	result := scanAllStatements(c, input)
+
+	c.Assert(result, qt.DeepEquals, []db.ScannedStatement{
+		{Text: "SELECT 1", Line: 1},
+		{Text: "SELECT\n  2", Line: 4},
+		{Text: "SELECT 3", Line: 5},
+		{Text: "SELECT 4", Line: 6},
+	})
+}
+
+func TestStatementScanner_GivenCreateTriggerStatement_ExpectTriggerBodyNotSplit(t *testing.T) {
+	c := qt.New(t)
+
+	input := "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (1);\n  INSERT INTO b VALUES (2);\nEND;\nSELECT 1;"
+	result := scanAllStatements(c, input)
+
+	c.Assert(result, qt.HasLen, 2)
+	c.Assert(result[0].Text, qt.Equals, "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (1);\n  INSERT INTO b VALUES (2);\nEND")
+	c.Assert(result[1], qt.DeepEquals, db.ScannedStatement{Text: "SELECT 1", Line: 5})
+}
+
+func TestStatementScanner_GivenSemicolonInsideString_ExpectSingleStatement(t *testing.T) {
+	c := qt.New(t)
+
+	input := "INSERT INTO t VALUES ('a;\nb');"
+	result := scanAllStatements(c, input)
+
+	c.Assert(result, qt.DeepEquals, []db.ScannedStatement{{Text: "INSERT INTO t VALUES ('a;\nb')", Line: 1}})
+}
+
+func TestStatementEndTracker_GivenLinesOfQuotedTextAndComments_ExpectOnlyLinesEndingWithASemicolonOutsideThemToFinish(t *testing.T) {
+	c := qt.New(t)
+
+	var tracker db.StatementEndTracker
+	for _, step := range []struct {
+		line      string
+		mayFinish bool
+	}{
+		{"INSERT INTO t VALUES ('abc;", false},
+		{"def'); -- done;", true},
+		{"SELECT 1; /* comment", false},
+		{"still a comment; */", true},
+		{"SELECT [a;", false},
+		{"b]", false},
+		{"", false},
+		{"  ;  ", true},
+	} {
+		c.Assert(tracker.ReadLine(step.line), qt.Equals, step.mayFinish, qt.Commentf("line %q", step.line))
+	}
+}
+
+func TestSplitStatementsWithLines_GivenTextStartingAtALine_ExpectStatementsWithTheirLine(t *testing.T) {
+	c := qt.New(t)
+
+	result := db.SplitStatementsWithLines("SELECT 1;\n\nSELECT\n  2; SELECT 3;", 10)
+
+	c.Assert(result, qt.DeepEquals, []db.ScannedStatement{
+		{Text: "SELECT 1", Line: 10},
+		{Text: "SELECT\n  2", Line: 12},
+		{Text: "SELECT 3", Line: 13},
+	})
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type DatabaseStats struct {
+	PageSize      int64
+	PageCount     int64
+	FreelistCount int64
+	Objects       []ObjectStats
+}
+
+func (s *DatabaseStats) Size() int64 {
+	return s.PageSize * s.PageCount
+}
+
+func (s *DatabaseStats) FreelistSize() int64 {
+	return s.PageSize * s.FreelistCount
+}
+
+type ObjectStats struct {
+	Name      string
+	Type      string
+	TableName string
+	Rows      int64
+	Bytes     int64
+	// Pages is only known when the sizes come from dbstat
+	Pages sql.NullInt64
+	// Estimated is true when dbstat is not available and Bytes is computed from the length of the stored values
+	Estimated bool
+}
+
+type storedObject struct {
+	objectType string
+	name       string
+	tableName  string
+	sql        sql.NullString
+}
+
+// GetStats reports the page usage of the database and the rows and bytes of each table and index, biggest first
+func (db *Db) GetStats() (*DatabaseStats, error) {
+	ctx := context.Background()
+	stats := &DatabaseStats{}
+
+	pragmas := []struct {
+		name  string
+		value *int64
+	}{
+		{"page_size", &stats.PageSize},
+		{"page_count", &stats.PageCount},
+		{"freelist_count", &stats.FreelistCount},
+	}
+	for _, pragma := range pragmas {
+		if err := db.sqlDb.QueryRowContext(ctx, "PRAGMA "+pragma.name).Scan(pragma.value); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", pragma.name, err)
+		}
+	}
+
+	objects, err := getStoredObjects(ctx, db.sqlDb)
+	if err != nil {
+		return nil, err
+	}
+
+	tableRows := make(map[string]int64)
+	for _, object := range objects {
+		if object.objectType != "table" {
+			continue
+		}
+		if tableRows[object.name], err = countRows(ctx, db.sqlDb, object.name); err != nil {
+			return nil, err
+		}
+	}
+
+	pageStats, dbstatAvailable := getDbstatStats(ctx, db.sqlDb)
+	for _, object := range objects {
+		objectStats := ObjectStats{Name: object.name, Type: object.objectType, TableName: object.tableName, Rows: tableRows[object.tableName]}
+
+		if dbstatAvailable {
+			pages := pageStats[object.name]
+			objectStats.Bytes = pages.bytes
+			objectStats.Pages = sql.NullInt64{Int64: pages.pages, Valid: true}
+			// entries of an index b-tree are also stored in its interior pages, unlike the rows of a table
+			if object.objectType == "index" {
+				objectStats.Rows = pages.cells
+			}
+		} else {
+			objectStats.Estimated = true
+			if objectStats.Bytes, err = estimateObjectBytes(ctx, db.sqlDb, object); err != nil {
+				return nil, err
+			}
+		}
+
+		stats.Objects = append(stats.Objects, objectStats)
+	}
+
+	sort.SliceStable(stats.Objects, func(i, j int) bool {
+		return stats.Objects[i].Bytes > stats.Objects[j].Bytes
+	})
+	return stats, nil
+}
+
+func getStoredObjects(ctx context.Context, source queryer) ([]storedObject, error) {
+	// virtual tables store nothing themselves, their content lives in shadow tables
+	rows, err := source.QueryContext(ctx, `SELECT type, name, tbl_name, sql FROM sqlite_schema
+		WHERE type IN ('table', 'index')
+		AND (name NOT LIKE 'sqlite_%' OR name LIKE 'sqlite_autoindex_%')
+		AND (sql IS NULL OR sql NOT LIKE 'CREATE VIRTUAL TABLE%')
+		ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := make([]storedObject, 0)
+	for rows.Next() {
+		var object storedObject
+		if err := rows.Scan(&object.objectType, &object.name, &object.tableName, &object.sql); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+type pageUsage struct {
+	pages int64
+	bytes int64
+	cells int64
+}
+
+// getDbstatStats reads the page usage of each object from the dbstat virtual table, which SQLite may be built without
+func getDbstatStats(ctx context.Context, source queryer) (map[string]pageUsage, bool) {
+	rows, err := source.QueryContext(ctx, "SELECT name, count(*), sum(pgsize), sum(ncell) FROM dbstat GROUP BY name")
+	if err != nil {
+		return nil, false
+	}
+	defer rows.Close()
+
+	usage := make(map[string]pageUsage)
+	for rows.Next() {
+		var name string
+		var pages pageUsage
+		if err := rows.Scan(&name, &pages.pages, &pages.bytes, &pages.cells); err != nil {
+			return nil, false
+		}
+		usage[name] = pages
+	}
+	return usage, rows.Err() == nil
+}
+
+// estimateObjectBytes adds up the length of the values stored by a table or an index.
+// Indexes also store the rowid, counted as 8 bytes
+func estimateObjectBytes(ctx context.Context, source queryer, object storedObject) (int64, error) {
+	var quotedColumns []string
+	if object.objectType == "table" {
+		columns, err := getInsertableColumns(ctx, source, object.name)
+		if err != nil {
+			return 0, err
+		}
+		quotedColumns = columns
+	} else {
+		columns, _, err := getIndexKeyColumns(ctx, source, "main", object.name)
+		if err != nil {
+			return 0, err
+		}
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, QuoteIdentifier(column))
+		}
+	}
+
+	lengths := make([]string, 0, len(quotedColumns)+1)
+	for _, column := range quotedColumns {
+		lengths = append(lengths, fmt.Sprintf("coalesce(length(%s), 0)", column))
+	}
+	if object.objectType == "index" {
+		lengths = append(lengths, "8")
+	}
+	if len(lengths) == 0 {
+		return 0, nil
+	}
+
+	rows, err := source.QueryContext(ctx, fmt.Sprintf("SELECT sum(%s) FROM %s", strings.Join(lengths, " + "), QuoteIdentifier(object.tableName)))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var bytes sql.NullInt64
+	if rows.Next() {
+		if err := rows.Scan(&bytes); err != nil {
+			return 0, err
+		}
+	}
+	return bytes.Int64, rows.Err()
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+	"github.com/tursodatabase/libsql-client-go/sqliteparserutils"
+)
+
+// TransactionLevel is a level of the stack of open transactions and savepoints
+type TransactionLevel struct {
+	// Savepoint is empty for the transaction opened by BEGIN
+	Savepoint string
+}
+
+// InTransaction tells if a transaction was opened by an executed BEGIN or SAVEPOINT and not closed yet
+func (db *Db) InTransaction() bool {
+	return len(db.transactionStack) > 0
+}
+
+// TransactionStack returns the open transaction followed by its savepoints, from the outermost to the innermost
+func (db *Db) TransactionStack() []TransactionLevel {
+	return append([]TransactionLevel{}, db.transactionStack...)
+}
+
+// trackTransaction follows the statements of an executed query, which holds several statements when sent as a batch
+func (db *Db) trackTransaction(query string) {
+	statements, _ := sqliteparserutils.SplitStatement(query)
+	for _, statement := range statements {
+		db.trackTransactionStatement(statement)
+	}
+}
+
+func (db *Db) trackTransactionStatement(statement string) {
+	tokens := getStatementTokens(statement)
+	if len(tokens) == 0 {
+		return
+	}
+
+	switch tokens[0].tokenType {
+	case sqliteparser.SQLiteLexerBEGIN_:
+		db.transactionStack = []TransactionLevel{{}}
+	case sqliteparser.SQLiteLexerCOMMIT_, sqliteparser.SQLiteLexerEND_:
+		db.transactionStack = nil
+	case sqliteparser.SQLiteLexerSAVEPOINT_:
+		// a savepoint outside a transaction opens one, which ends when the savepoint is released
+		if savepoint := getSavepointName(tokens[1:]); savepoint != "" {
+			db.transactionStack = append(db.transactionStack, TransactionLevel{Savepoint: savepoint})
+		}
+	case sqliteparser.SQLiteLexerRELEASE_:
+		if level := db.findSavepoint(getSavepointName(tokens[1:])); level >= 0 {
+			db.transactionStack = db.transactionStack[:level]
+		}
+	case sqliteparser.SQLiteLexerROLLBACK_:
+		for i, token := range tokens {
+			if token.tokenType != sqliteparser.SQLiteLexerTO_ {
+				continue
+			}
+			// ROLLBACK TO keeps the savepoint open, and the transaction too
+			if level := db.findSavepoint(getSavepointName(tokens[i+1:])); level >= 0 {
+				db.transactionStack = db.transactionStack[:level+1]
+			}
+			return
+		}
+		db.transactionStack = nil
+	}
+}
+
+// findSavepoint returns the level of the innermost savepoint with the name, or -1. Names are case insensitive
+func (db *Db) findSavepoint(savepoint string) int {
+	for level := len(db.transactionStack) - 1; level >= 0; level-- {
+		if db.transactionStack[level].Savepoint != "" && strings.EqualFold(db.transactionStack[level].Savepoint, savepoint) {
+			return level
+		}
+	}
+	return -1
+}
+
+// getSavepointName reads "?SAVEPOINT? name", ignoring the trailing semicolon
+func getSavepointName(tokens []statementToken) string {
+	if len(tokens) > 0 && tokens[0].tokenType == sqliteparser.SQLiteLexerSAVEPOINT_ {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || tokens[0].tokenType == sqliteparser.SQLiteLexerSCOL {
+		return ""
+	}
+	return tokens[0].text
+}
+
+// BufferedStatementsCount returns how many statements of the open transaction are kept until it's committed
+func (db *Db) BufferedStatementsCount() int {
+	return len(db.bufferedTransaction)
+}
+
+// isHttp tells if each query is sent in its own HTTP request, which can't share a transaction with the others
+func (db *Db) isHttp() bool {
+	return db.driver == libsqlDriver && (db.urlScheme == "http" || db.urlScheme == "https")
+}
+
+// bufferHttpTransaction keeps the statements of a transaction typed over several inputs, from BEGIN or SAVEPOINT until
+// COMMIT, and returns the queries to send now: the statements outside the transaction, and the whole transaction as
+// one batch once it's committed. A transaction rolled back is discarded without sending it. The values of the parameters
+// are written into the buffered statements, since they may have changed by the time the transaction is committed
+func (db *Db) bufferHttpTransaction(statementsString string) []string {
+	statements, _ := sqliteparserutils.SplitStatement(statementsString)
+
+	queries := make([]string, 0)
+	outsideTransaction := make([]string, 0)
+	flushOutsideTransaction := func() {
+		if len(outsideTransaction) > 0 {
+			queries = append(queries, joinStatements(outsideTransaction)...)
+			outsideTransaction = make([]string, 0)
+		}
+	}
+
+	for _, statement := range statements {
+		tokens := getStatementTokens(statement)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		startsTransaction := tokens[0].tokenType == sqliteparser.SQLiteLexerBEGIN_ || tokens[0].tokenType == sqliteparser.SQLiteLexerSAVEPOINT_
+		if db.bufferedTransaction == nil && !startsTransaction {
+			outsideTransaction = append(outsideTransaction, statement)
+			continue
+		}
+		if db.bufferedTransaction == nil {
+			flushOutsideTransaction()
+		}
+
+		db.trackTransactionStatement(statement)
+		if tokens[0].tokenType == sqliteparser.SQLiteLexerROLLBACK_ && !db.InTransaction() {
+			db.bufferedTransaction = nil
+			continue
+		}
+
+		db.bufferedTransaction = append(db.bufferedTransaction, inlineParameterValues(statement, db.parameters))
+		if !db.InTransaction() {
+			queries = append(queries, strings.Join(db.bufferedTransaction, ";\n")+";")
+			db.bufferedTransaction = nil
+		}
+	}
+	flushOutsideTransaction()
+
+	return queries
+}
+
+// joinStatements sends statements as one batch, unless they have parameters, which are bound to each statement on its own
+func joinStatements(statements []string) []string {
+	joined := strings.Join(statements, ";\n") + ";"
+	if hasBindParameters(joined) {
+		return statements
+	}
+	return []string{joined}
+}
+
+// inlineParameterValues replaces the parameters of the statement with their values as SQL literals
+func inlineParameterValues(statement string, parameters Parameters) string {
+	text := []rune(statement)
+	lexer := sqliteparser.NewSQLiteLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+
+	var inlined strings.Builder
+	indexer := newBindParameterIndexer()
+	position := 0
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if token.GetTokenType() != sqliteparser.SQLiteLexerBIND_PARAMETER {
+			continue
+		}
+		inlined.WriteString(string(text[position:token.GetStart()]))
+		inlined.WriteString(getSQLLiteral(parameters[indexer.next(token.GetText()).name]))
+		position = token.GetStop() + 1
+	}
+	inlined.WriteString(string(text[position:]))
+	return inlined.String()
+}
+
+func getSQLLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case float64:
+		// the exponent keeps whole numbers REAL
+		return strconv.FormatFloat(v, 'e', -1, 64)
+	case string:
+		return "'" + EscapeSingleQuotes(v) + "'"
+	case []byte:
+		return fmt.Sprintf("X'%X'", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
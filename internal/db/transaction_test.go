@@ -0,0 +1,90 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+func TestInTransaction_GivenExecutedTransactionStatements_ExpectTransactionStateToFollowThem(t *testing.T) {
+	c := qt.New(t)
+
+	database, err := db.NewDb(filepath.Join(c.TempDir(), "test.sqlite"), "", "", false, "")
+	c.Assert(err, qt.IsNil)
+	defer database.Close()
+
+	for _, step := range []struct {
+		statements    string
+		inTransaction bool
+	}{
+		{"CREATE TABLE t (id INTEGER);", false},
+		{"BEGIN;", true},
+		{"SAVEPOINT s; INSERT INTO t VALUES (1); ROLLBACK TO s;", true},
+		{"COMMIT;", false},
+		{"BEGIN IMMEDIATE; INSERT INTO t VALUES (1);", true},
+		{"ROLLBACK;", false},
+		{"COMMIT;", false},
+	} {
+		_, _ = database.ExecuteStatementsDiscardingRows(step.statements)
+		c.Assert(database.InTransaction(), qt.Equals, step.inTransaction, qt.Commentf("after %s", step.statements))
+	}
+}
+
+func TestExecuteStatements_GivenPartlyReadResult_ExpectNextStatementsNotBlocked(t *testing.T) {
+	c := qt.New(t)
+
+	database, err := db.NewDb(filepath.Join(c.TempDir(), "test.sqlite"), "", "", false, "")
+	c.Assert(err, qt.IsNil)
+	defer database.Close()
+
+	result, err := database.ExecuteStatements("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n LIMIT 100) SELECT i FROM n;")
+	c.Assert(err, qt.IsNil)
+	statementResult := <-result.StatementResultCh
+	c.Assert(statementResult.Err, qt.IsNil)
+	firstRow := <-statementResult.RowCh
+	c.Assert(firstRow.Err, qt.IsNil)
+
+	done := make(chan error)
+	go func() {
+		_, err := database.ExecuteStatementsDiscardingRows("CREATE TABLE t (id INTEGER);")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		c.Assert(err, qt.IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("statements blocked by the partly read result")
+	}
+}
+
+func TestExecuteStatements_GivenHttpDatabase_ExpectTransactionBufferedUntilCommit(t *testing.T) {
+	c := qt.New(t)
+
+	// nothing listens on this address, so any statement sent to it fails
+	database, err := db.NewDb("http://127.0.0.1:1", "", "", false, "")
+	c.Assert(err, qt.IsNil)
+	defer database.Close()
+
+	_, err = database.ExecuteStatementsDiscardingRows("BEGIN; INSERT INTO t VALUES (1);")
+	c.Assert(err, qt.IsNil)
+	_, err = database.ExecuteStatementsDiscardingRows("SAVEPOINT s; INSERT INTO t VALUES (2); ROLLBACK TO s;")
+	c.Assert(err, qt.IsNil)
+	c.Assert(database.BufferedStatementsCount(), qt.Equals, 5)
+	c.Assert(database.TransactionStack(), qt.DeepEquals, []db.TransactionLevel{{}, {Savepoint: "s"}})
+
+	_, err = database.ExecuteStatementsDiscardingRows("ROLLBACK;")
+	c.Assert(err, qt.IsNil)
+	c.Assert(database.BufferedStatementsCount(), qt.Equals, 0)
+	c.Assert(database.InTransaction(), qt.IsFalse)
+
+	_, err = database.ExecuteStatementsDiscardingRows("BEGIN; INSERT INTO t VALUES (1);")
+	c.Assert(err, qt.IsNil)
+	_, err = database.ExecuteStatementsDiscardingRows("COMMIT;")
+	c.Assert(err, qt.IsNotNil)
+	c.Assert(database.BufferedStatementsCount(), qt.Equals, 0)
+	c.Assert(database.InTransaction(), qt.IsFalse)
+}
@@ -45,3 +45,7 @@ func NeedsEscaping(name string) bool {
 	}
 	return false
 }
+
+func QuoteIdentifier(name string) string {
+	return "\"" + strings.Replace(name, "\"", "\"\"", -1) + "\""
+}
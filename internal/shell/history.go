@@ -6,6 +6,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
 
 	"github.com/libsql/libsql-shell-go/internal/db"
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
@@ -66,3 +70,36 @@ func getFileNameWithoutExtension(path string) string {
 	}
 	return filenameWithoutExtension
 }
+
+// GetHistoryEntry puts statements written over several lines on a single one, since the history file keeps an entry per
+// line. Line comments become block comments, so they don't swallow what followed them. Statements with a line break
+// inside a string or a quoted identifier can't be put on a single line without changing them, so no entry is returned
+func GetHistoryEntry(statements string) string {
+	lexer := sqliteparser.NewSQLiteLexer(antlr.NewInputStream(statements))
+	lexer.RemoveErrorListeners()
+
+	var entry strings.Builder
+	separate := func() {
+		if entry.Len() > 0 && !strings.HasSuffix(entry.String(), " ") {
+			entry.WriteString(" ")
+		}
+	}
+	for token := lexer.NextToken(); token.GetTokenType() != antlr.TokenEOF; token = lexer.NextToken() {
+		switch token.GetTokenType() {
+		case sqliteparser.SQLiteLexerSPACES:
+			separate()
+		case sqliteparser.SQLiteLexerSINGLE_LINE_COMMENT:
+			comment := strings.TrimSpace(strings.TrimPrefix(token.GetText(), "--"))
+			entry.WriteString("/* " + strings.ReplaceAll(comment, "*/", "* /") + " */")
+			separate()
+		case sqliteparser.SQLiteLexerMULTILINE_COMMENT:
+			entry.WriteString(strings.Join(strings.Fields(token.GetText()), " "))
+		default:
+			if strings.ContainsAny(token.GetText(), "\r\n") {
+				return ""
+			}
+			entry.WriteString(token.GetText())
+		}
+	}
+	return strings.TrimSpace(entry.String())
+}
@@ -127,3 +127,20 @@ func TestGetHistoryFileBasedOnMode_GivenPerDatabaseHistory_WhenPathIsWsUrl_Expec
 
 	c.Assert(result, qt.Equals, expectedPath)
 }
+
+func TestGetHistoryEntry_GivenStatementsInMultipleLines_ExpectSingleLineWithLineCommentsAsBlockComments(t *testing.T) {
+	c := qt.New(t)
+
+	statements := "WITH recent AS (\n  SELECT * FROM t -- last day\n  WHERE d > 'a  b' /* from\n the form */\n)\nSELECT * FROM recent;"
+	result := shell.GetHistoryEntry(statements)
+
+	c.Assert(result, qt.Equals, "WITH recent AS ( SELECT * FROM t /* last day */ WHERE d > 'a  b' /* from the form */ ) SELECT * FROM recent;")
+}
+
+func TestGetHistoryEntry_GivenALineBreakInsideAString_ExpectNoEntry(t *testing.T) {
+	c := qt.New(t)
+
+	result := shell.GetHistoryEntry("SELECT * FROM t\nWHERE d > 'a\nb';")
+
+	c.Assert(result, qt.Equals, "")
+}
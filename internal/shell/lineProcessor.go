@@ -0,0 +1,71 @@
+package shell
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+const editCommand = ".edit"
+
+// lineProcessor groups lines of input the way the shell reads them: a line starting with a dot is a command, unless
+// it continues a statement, and statements are gathered over several lines until they are finished
+type lineProcessor struct {
+	statement strings.Builder
+	end       db.StatementEndTracker
+
+	// linesRead counts the processed lines, and statementLine is the line the pending or last finished statements
+	// start at
+	linesRead     int
+	statementLine int
+}
+
+// process returns the command of the line or the statements it finishes. Both are empty when the line is blank or
+// starts or continues a statement. Statements keep the text of their lines, since it can be part of a string literal
+// spanning several lines, and the trimmed line only tells commands and blank lines apart
+func (p *lineProcessor) process(line string) (command string, statements string) {
+	p.linesRead++
+	line = strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimSpace(line)
+
+	// .edit can also be typed in the middle of a statement, to finish it in the editor
+	if p.insideStatement() && trimmed == editCommand {
+		return trimmed, ""
+	}
+
+	if p.insideStatement() {
+		p.statement.WriteString("\n")
+	} else {
+		if trimmed == "" {
+			return "", ""
+		}
+		if isCommand(trimmed) {
+			return trimmed, ""
+		}
+		p.statementLine = p.linesRead
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	}
+	p.statement.WriteString(line)
+
+	if !p.end.ReadLine(line) || !isStatementFinished(p.statement.String()) {
+		return "", ""
+	}
+	return "", p.flush()
+}
+
+func (p *lineProcessor) insideStatement() bool {
+	return p.statement.Len() > 0
+}
+
+func (p *lineProcessor) pending() string {
+	return p.statement.String()
+}
+
+// flush returns the unfinished statement, if any, and forgets it
+func (p *lineProcessor) flush() string {
+	statement := p.statement.String()
+	p.statement.Reset()
+	p.end.Reset()
+	return statement
+}
@@ -0,0 +1,47 @@
+package shell
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
+)
+
+type PromptValues struct {
+	DbUri       string
+	Connection  string
+	Mode        enums.PrintMode
+	Transaction []db.TransactionLevel
+}
+
+// RenderPromptTemplate replaces the placeholders of the template: {db} with the host of the database, or its file name
+// if local, {conn} with the name of the active connection, {mode} with the print mode and {tx} with the transaction
+// state, see getTransactionDisplay
+func RenderPromptTemplate(template string, values PromptValues) string {
+	return strings.NewReplacer(
+		"{db}", getDatabaseDisplayName(values.DbUri),
+		"{conn}", values.Connection,
+		"{mode}", string(values.Mode),
+		"{tx}", getTransactionDisplay(values.Transaction),
+	).Replace(template)
+}
+
+// getTransactionDisplay returns "*" while a transaction is open, followed by the name of the innermost savepoint if any
+func getTransactionDisplay(transaction []db.TransactionLevel) string {
+	if len(transaction) == 0 {
+		return ""
+	}
+	return "*" + transaction[len(transaction)-1].Savepoint
+}
+
+func getDatabaseDisplayName(dbUri string) string {
+	if db.IsUrl(dbUri) {
+		if parsedUrl, err := url.Parse(dbUri); err == nil && parsedUrl.Host != "" {
+			return parsedUrl.Hostname()
+		}
+		return dbUri
+	}
+	return filepath.Base(dbUri)
+}
@@ -0,0 +1,39 @@
+package shell_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+
+	"github.com/libsql/libsql-shell-go/internal/shell"
+	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
+)
+
+func TestRenderPromptTemplate_GivenLocalDatabaseInTransaction_ExpectFileNameAndTransactionMarker(t *testing.T) {
+	c := qt.New(t)
+
+	values := shell.PromptValues{DbUri: "/path/to/my/db.sqlite", Connection: "default", Mode: enums.CSV_MODE, Transaction: []db.TransactionLevel{{}}}
+	result := shell.RenderPromptTemplate("{conn}:{db}{tx} ({mode}) > ", values)
+
+	c.Assert(result, qt.Equals, "default:db.sqlite* (csv) > ")
+}
+
+func TestRenderPromptTemplate_GivenRemoteDatabaseWithoutTransaction_ExpectHostWithoutTransactionMarker(t *testing.T) {
+	c := qt.New(t)
+
+	values := shell.PromptValues{DbUri: "libsql://my-db.turso.io:8080?tls=0", Connection: "default", Mode: enums.TABLE_MODE}
+	result := shell.RenderPromptTemplate("{db}{tx} > ", values)
+
+	c.Assert(result, qt.Equals, "my-db.turso.io > ")
+}
+
+func TestRenderPromptTemplate_GivenOpenSavepoints_ExpectInnermostSavepoint(t *testing.T) {
+	c := qt.New(t)
+
+	values := shell.PromptValues{DbUri: "db.sqlite", Transaction: []db.TransactionLevel{{}, {Savepoint: "outer"}, {Savepoint: "inner"}}}
+	result := shell.RenderPromptTemplate("{tx} > ", values)
+
+	c.Assert(result, qt.Equals, "*inner > ")
+}
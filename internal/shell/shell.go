@@ -1,8 +1,12 @@
 package shell
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -23,6 +27,11 @@ const DEFAULT_WELCOME_MESSAGE = "Welcome to LibSQL shell!\n\nType \".quit\" to e
 const promptNewStatement = "→  "
 const promptContinueStatement = "... "
 
+const defaultConnectionName = "default"
+
+// maxScriptDepth stops scripts that read themselves, directly or not
+const maxScriptDepth = 64
+
 type ShellConfig struct {
 	InF                   io.Reader
 	OutF                  io.Writer
@@ -32,6 +41,9 @@ type ShellConfig struct {
 	QuietMode             bool
 	WelcomeMessage        *string
 	DisableAutoCompletion bool
+	Echo                  bool
+	// PromptTemplate is the prompt of new statements, see renderPromptTemplate. An empty one means the default prompt
+	PromptTemplate string
 }
 
 type Shell struct {
@@ -40,26 +52,48 @@ type Shell struct {
 	db        *db.Db
 	promptFmt func(p ...interface{}) string
 
+	connections      []*connection
+	activeConnection *connection
+
 	state shellState
 
+	dbCmdConfig *shellcmd.DbCmdConfig
 	databaseCmd *cobra.Command
 }
 
+type connection struct {
+	name string
+	db   *db.Db
+}
+
 type shellState struct {
 	readline                   *readline.Instance
-	statementParts             []string
-	insideMultilineStatement   bool
+	lines                      lineProcessor
 	interruptReadEvalPrintLoop bool
 	printMode                  enums.PrintMode
+	eqpMode                    shellcmd.EqpMode
+	bail                       bool
+	echo                       bool
+	parameters                 db.Parameters
+	promptTemplate             string
+	// scriptDirs holds the directory of each script being read, from the outermost to the innermost
+	scriptDirs []string
+	// lastStatements are the statements typed last, which .edit opens
+	lastStatements string
+	// commandLine is the line of the command being executed
+	commandLine string
 }
 
 func NewShell(config ShellConfig, db *db.Db) (*Shell, error) {
 	promptFmt := color.New(color.FgBlue, color.Bold).SprintFunc()
 
 	newShell := Shell{config: config, db: db, promptFmt: promptFmt}
+	newShell.activeConnection = &connection{name: defaultConnectionName, db: db}
+	newShell.connections = []*connection{newShell.activeConnection}
 
 	dbCmdConfig := &shellcmd.DbCmdConfig{
 		Db:                db,
+		InF:               config.InF,
 		OutF:              config.OutF,
 		ErrF:              config.ErrF,
 		SetInterruptShell: func() { newShell.state.interruptReadEvalPrintLoop = true },
@@ -67,7 +101,28 @@ func NewShell(config ShellConfig, db *db.Db) (*Shell, error) {
 		GetMode: func() enums.PrintMode {
 			return newShell.state.printMode
 		},
+		SetEqpMode:     func(mode shellcmd.EqpMode) { newShell.state.eqpMode = mode },
+		GetEqpMode:     func() shellcmd.EqpMode { return newShell.state.eqpMode },
+		SetBail:        func(bail bool) { newShell.state.bail = bail },
+		GetBail:        func() bool { return newShell.state.bail },
+		SetEcho:        func(echo bool) { newShell.state.echo = echo },
+		GetEcho:        func() bool { return newShell.state.echo },
+		GetParameters:  newShell.getParameters,
+		GetCommandLine: func() string { return newShell.state.commandLine },
+		SetPromptTemplate: func(template string) {
+			newShell.state.promptTemplate = template
+			newShell.state.readline.SetPrompt(newShell.newStatementPrompt())
+		},
+		GetPromptTemplate:       func() string { return newShell.state.promptTemplate },
+		SetDb:                   newShell.setDb,
+		OpenConnection:          newShell.openConnection,
+		UseConnection:           newShell.useConnection,
+		GetConnections:          newShell.getConnections,
+		ExecuteScript:           newShell.executeScript,
+		GetEditableStatements:   newShell.getEditableStatements,
+		ExecuteEditedStatements: newShell.executeEditedStatements,
 	}
+	newShell.dbCmdConfig = dbCmdConfig
 	newShell.databaseCmd = shellcmd.CreateNewDatabaseRootCmd(dbCmdConfig)
 
 	err := newShell.resetState()
@@ -80,38 +135,50 @@ func NewShell(config ShellConfig, db *db.Db) (*Shell, error) {
 
 func (sh *Shell) Run() error {
 	defer sh.state.readline.Close()
+	defer sh.rollbackOpenTransactions()
 
 	if !sh.config.QuietMode {
 		fmt.Print(sh.getWelcomeMessage())
 	}
 
 	for !sh.state.interruptReadEvalPrintLoop {
+		// the database, the transaction state and the mode may have changed since the prompt was rendered
+		if sh.state.lines.insideStatement() {
+			sh.state.readline.SetPrompt(sh.promptFmt(promptContinueStatement))
+		} else {
+			sh.state.readline.SetPrompt(sh.newStatementPrompt())
+		}
+
 		line, err := sh.state.readline.Readline()
 
 		if err == readline.ErrInterrupt {
-			if len(line) == 0 {
+			if len(line) == 0 && sh.confirmQuit() {
 				return nil
 			} else {
 				continue
 			}
 		} else if err == io.EOF {
-			break
+			// Ctrl-D quits like .quit does, but the end of a piped input leaves nobody to answer
+			if !sh.isInteractive() || sh.confirmQuit() {
+				break
+			}
+			continue
 		}
 
-		line = strings.TrimSpace(line)
+		command, statements := sh.state.lines.process(line)
 
 		switch {
-		case len(line) == 0:
-			continue
-		case sh.state.insideMultilineStatement:
-			sh.appendStatementPartAndExecuteIfFinished(line)
-		case isCommand(line):
-			err = sh.executeCommand(line)
+		case command != "":
+			err = sh.executeCommand(command)
 			if err != nil {
 				db.PrintError(err, sh.config.ErrF)
 			}
-		default:
-			sh.appendStatementPartAndExecuteIfFinished(line)
+			// without a terminal nobody answers, so open transactions are rolled back when the shell stops
+			if sh.state.interruptReadEvalPrintLoop && sh.isInteractive() && !sh.confirmQuit() {
+				sh.state.interruptReadEvalPrintLoop = false
+			}
+		case statements != "":
+			sh.executeTypedStatements(statements)
 		}
 
 	}
@@ -119,18 +186,24 @@ func (sh *Shell) Run() error {
 }
 
 func (sh *Shell) resetState() error {
+	sh.state.promptTemplate = sh.config.PromptTemplate
+
 	var err error
 	sh.state.readline, err = sh.newReadline()
 	if err != nil {
 		return err
 	}
 
-	sh.state.insideMultilineStatement = false
-	sh.state.statementParts = make([]string, 0)
+	sh.state.lines = lineProcessor{}
 
 	sh.state.interruptReadEvalPrintLoop = false
 
 	sh.state.printMode = enums.TABLE_MODE
+	sh.state.eqpMode = shellcmd.EqpOff
+	sh.state.bail = true
+	sh.state.echo = sh.config.Echo
+	sh.state.parameters = make(db.Parameters)
+	sh.db.SetParameters(sh.state.parameters)
 
 	return nil
 }
@@ -154,13 +227,15 @@ func (sac *shellAutoCompleter) Do(line []rune, pos int) (newLine [][]rune, lengh
 	return runeSuggestions, pos
 }
 
-func (sh *Shell) newReadline() (*readline.Instance, error) {
-	historyFile := GetHistoryFileBasedOnMode(sh.db.Uri, sh.config.HistoryMode, sh.config.HistoryName)
+func (sh *Shell) getHistoryFile() string {
+	return GetHistoryFileBasedOnMode(sh.db.Uri, sh.config.HistoryMode, sh.config.HistoryName)
+}
 
+func (sh *Shell) newReadline() (*readline.Instance, error) {
 	config := &readline.Config{
-		Prompt:          sh.promptFmt(promptNewStatement),
+		Prompt:          sh.newStatementPrompt(),
 		InterruptPrompt: "^C",
-		HistoryFile:     historyFile,
+		HistoryFile:     sh.getHistoryFile(),
 		EOFPrompt:       QUIT_COMMAND,
 		Stdin:           io.NopCloser(sh.config.InF),
 		Stdout:          sh.config.OutF,
@@ -175,12 +250,36 @@ func (sh *Shell) newReadline() (*readline.Instance, error) {
 	return readline.NewEx(config)
 }
 
+// newStatementPrompt renders the prompt template. The default prompt shows the name of the active connection once there
+// is more than one to choose from, and the transaction state while a transaction is open
+func (sh *Shell) newStatementPrompt() string {
+	template := sh.state.promptTemplate
+	if template == "" {
+		template = promptNewStatement
+		if sh.db.InTransaction() {
+			template = "{tx} " + template
+		}
+		if len(sh.connections) > 1 {
+			template = "{conn} " + template
+		}
+	}
+
+	return sh.promptFmt(RenderPromptTemplate(template, PromptValues{
+		DbUri:       sh.db.Uri,
+		Connection:  sh.activeConnection.name,
+		Mode:        sh.state.printMode,
+		Transaction: sh.db.TransactionStack(),
+	}))
+}
+
 func isCommand(line string) bool {
 	return line[0] == '.'
 }
 
 func (sh *Shell) executeCommand(command string) error {
+	sh.state.commandLine = command
 	parts := strings.Fields(command)
+	shellcmd.ResetFlags(sh.databaseCmd)
 	sh.databaseCmd.SetArgs(parts)
 
 	err := sh.databaseCmd.Execute()
@@ -193,21 +292,96 @@ func (sh *Shell) executeCommand(command string) error {
 	return err
 }
 
-func (sh *Shell) appendStatementPartAndExecuteIfFinished(statementPart string) {
-	sh.state.statementParts = append(sh.state.statementParts, statementPart)
-	completeStatement := strings.Join(sh.state.statementParts, "\n")
-	if isStatementFinished(completeStatement) {
-		sh.state.statementParts = make([]string, 0)
-		sh.state.insideMultilineStatement = false
-		sh.state.readline.SetPrompt(sh.promptFmt(promptNewStatement))
-		err := sh.db.ExecuteAndPrintStatements(completeStatement, sh.config.OutF, false, sh.state.printMode)
+// executeTypedStatements executes the statements typed in the shell, asking for the values of their unbound parameters
+func (sh *Shell) executeTypedStatements(statements string) {
+	sh.state.lastStatements = statements
+
+	parameters, answered := sh.promptUnboundParameters(statements)
+	if !answered {
+		return
+	}
+
+	sh.db.SetParameters(parameters)
+	err := sh.executeAndPrintStatements(statements)
+	sh.db.SetParameters(sh.state.parameters)
+	if err != nil {
+		db.PrintError(err, sh.state.readline.Stderr())
+	}
+	sh.printBufferedTransactionNote()
+}
+
+// getEditableStatements returns the statement being typed, or else the statements typed last
+func (sh *Shell) getEditableStatements() string {
+	if sh.state.lines.insideStatement() {
+		return sh.state.lines.pending()
+	}
+	return sh.state.lastStatements
+}
+
+// executeEditedStatements executes the statements written in the editor like the typed ones, saving them in history as
+// a single entry when they fit in one line. They replace the statement being typed, if any
+func (sh *Shell) executeEditedStatements(statements string) {
+	sh.state.lines.flush()
+
+	statements = strings.TrimSpace(statements)
+	if statements == "" {
+		return
+	}
+
+	if entry := GetHistoryEntry(statements); entry != "" {
+		if err := sh.state.readline.SaveHistory(entry); err != nil {
+			db.PrintError(err, sh.config.ErrF)
+		}
+	}
+	sh.executeTypedStatements(statements)
+}
+
+// promptUnboundParameters asks for the value of each parameter of the statements without one, hinting the type of its
+// column when known. The values are only bound to these statements, unlike the ones set with .param.
+// It returns false if the user gave up answering
+func (sh *Shell) promptUnboundParameters(statements string) (db.Parameters, bool) {
+	// the lines following the statements in a pipe or a redirected file aren't answers, so parameters without a value
+	// are bound to NULL instead
+	if !sh.isInteractive() {
+		return sh.state.parameters, true
+	}
+
+	hints := sh.db.GetUnboundParameters(statements)
+	if len(hints) == 0 {
+		return sh.state.parameters, true
+	}
+
+	parameters := make(db.Parameters, len(sh.state.parameters)+len(hints))
+	for name, value := range sh.state.parameters {
+		parameters[name] = value
+	}
+
+	sh.state.readline.HistoryDisable()
+	defer sh.state.readline.HistoryEnable()
+	for _, hint := range hints {
+		prompt := hint.Name
+		if hint.Type != "" {
+			prompt += " (" + hint.Type + ")"
+		}
+		sh.state.readline.SetPrompt(sh.promptFmt(prompt + ": "))
+
+		value, err := sh.state.readline.Readline()
+		if err == io.EOF {
+			// the input ended before the statements could be executed
+			sh.state.interruptReadEvalPrintLoop = true
+		}
 		if err != nil {
-			db.PrintError(err, sh.state.readline.Stderr())
+			return nil, false
 		}
-	} else {
-		sh.state.readline.SetPrompt(sh.promptFmt(promptContinueStatement))
-		sh.state.insideMultilineStatement = true
+		parameters[hint.Name] = db.ParseParameterValue(strings.TrimSpace(value))
 	}
+	return parameters, true
+}
+
+// isInteractive reports whether the input of the shell is a terminal, so questions can be asked on it
+func (sh *Shell) isInteractive() bool {
+	file, ok := sh.config.InF.(*os.File)
+	return ok && readline.IsTerminal(int(file.Fd()))
 }
 
 func (sh *Shell) ExecuteCommandOrStatements(commandOrStatements string) error {
@@ -215,7 +389,139 @@ func (sh *Shell) ExecuteCommandOrStatements(commandOrStatements string) error {
 		return sh.executeCommand(commandOrStatements)
 	}
 
-	return sh.db.ExecuteAndPrintStatements(commandOrStatements, sh.config.OutF, false, sh.state.printMode)
+	err := sh.executeAndPrintStatements(commandOrStatements)
+	sh.printBufferedTransactionNote()
+	return err
+}
+
+// executeScript runs the lines of a script through the same steps as the lines typed in the shell, so it can hold dot
+// commands as well as statements. The script is read one line at a time and each statement runs as soon as it's
+// complete, so files of any size can be read. Relative paths of nested .read commands are resolved from the directory
+// of the script that contains them, and "-" reads the standard input. Unbound parameters are left NULL instead of
+// asked for
+func (sh *Shell) executeScript(path string) error {
+	if len(sh.state.scriptDirs) >= maxScriptDepth {
+		return fmt.Errorf("too many nested .read commands, the limit is %d", maxScriptDepth)
+	}
+
+	input, dir, name := sh.config.InF, "", "the standard input"
+	if path != "-" {
+		if !filepath.IsAbs(path) && len(sh.state.scriptDirs) > 0 {
+			path = filepath.Join(sh.state.scriptDirs[len(sh.state.scriptDirs)-1], path)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input, dir, name = file, filepath.Dir(path), path
+	}
+
+	sh.state.scriptDirs = append(sh.state.scriptDirs, dir)
+	defer func() {
+		sh.state.scriptDirs = sh.state.scriptDirs[:len(sh.state.scriptDirs)-1]
+		if len(sh.state.scriptDirs) == 0 {
+			sh.printBufferedTransactionNote()
+		}
+	}()
+
+	var lines lineProcessor
+	reader := bufio.NewReader(input)
+	executed, failed := 0, 0
+	// execute runs a command or statement of the script, stopping at the first error unless bail is off
+	execute := func(run func() error, statement string, line int) error {
+		executed++
+		err := run()
+		if err == nil {
+			return nil
+		}
+
+		// the errors of nested scripts are already located in their own file
+		var statementErr *db.StatementError
+		if !errors.As(err, &statementErr) {
+			err = &db.StatementError{Index: executed, Statement: statement, Err: err, File: name, Line: line}
+		}
+		if sh.state.bail {
+			return err
+		}
+		failed++
+		db.PrintError(err, sh.config.ErrF)
+		return nil
+	}
+
+	for !sh.state.interruptReadEvalPrintLoop {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		command, statements := lines.process(line)
+		if readErr == io.EOF && command == "" && statements == "" {
+			// the last statement of a file doesn't need a semicolon
+			statements = lines.flush()
+		}
+
+		if command != "" {
+			if err := execute(func() error { return sh.executeCommand(command) }, command, lines.linesRead); err != nil {
+				return err
+			}
+		}
+		for _, statement := range db.SplitStatementsWithLines(statements, lines.statementLine) {
+			text := strings.TrimSpace(statement.Text)
+			if text == "" {
+				continue
+			}
+			if err := execute(func() error { return sh.executeAndPrintStatement(text) }, text, statement.Line); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return &db.FailedStatementsError{Failed: failed, Total: executed}
+	}
+	return nil
+}
+
+// printBufferedTransactionNote explains why the statements of a transaction on an HTTP connection have no results yet
+func (sh *Shell) printBufferedTransactionNote() {
+	if count := sh.db.BufferedStatementsCount(); count > 0 {
+		fmt.Fprintf(sh.config.ErrF, "Note: HTTP connections can't keep a transaction open, so its %d statements are sent together on COMMIT. Their results will be printed then\n", count)
+	}
+}
+
+func (sh *Shell) executeAndPrintStatements(statements string) error {
+	if !sh.state.bail {
+		return db.ExecuteEachStatement(statements, sh.config.ErrF, sh.executeAndPrintStatement)
+	}
+
+	if sh.state.eqpMode == shellcmd.EqpOff && !sh.state.echo {
+		return sh.db.ExecuteAndPrintTypedStatements(statements, sh.config.OutF, false, sh.state.printMode)
+	}
+
+	// the plan and the text of each statement are printed right before its results
+	splitStatements, _ := sqliteparserutils.SplitStatement(statements)
+	for _, statement := range splitStatements {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := sh.executeAndPrintStatement(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sh *Shell) executeAndPrintStatement(statement string) error {
+	shellcmd.PrintAutomaticExplanation(sh.db, sh.config.OutF, statement, sh.state.eqpMode, sh.state.printMode)
+	if sh.state.echo {
+		return sh.db.ExecuteAndPrintStatementWithEcho(statement, sh.config.OutF, false, sh.state.printMode)
+	}
+	return sh.db.ExecuteAndPrintTypedStatements(statement, sh.config.OutF, false, sh.state.printMode)
 }
 
 func (sh *Shell) getWelcomeMessage() string {
@@ -229,6 +535,153 @@ func (sh *Shell) CancelQuery() {
 	sh.db.CancelQuery()
 }
 
+func (sh *Shell) getParameters() db.Parameters {
+	return sh.state.parameters
+}
+
+// setDb closes the database of the active connection and replaces it with newDb
+func (sh *Shell) setDb(newDb *db.Db) {
+	previousDb := sh.activeConnection.db
+	sh.activeConnection.db = newDb
+	sh.activateConnection(sh.activeConnection)
+
+	sh.closeDb(sh.activeConnection.name, previousDb)
+}
+
+// openConnection makes newDb the active connection under the given name, closing the database it replaces if any
+func (sh *Shell) openConnection(name string, newDb *db.Db) {
+	if existing := sh.getConnection(name); existing != nil {
+		previousDb := existing.db
+		existing.db = newDb
+		sh.activateConnection(existing)
+		sh.closeDb(existing.name, previousDb)
+		return
+	}
+
+	newConnection := &connection{name: name, db: newDb}
+	sh.connections = append(sh.connections, newConnection)
+	sh.activateConnection(newConnection)
+}
+
+func (sh *Shell) useConnection(name string) error {
+	existing := sh.getConnection(name)
+	if existing == nil {
+		return fmt.Errorf("connection %s does not exist. Use \".connect %s URL_OR_PATH\" to open it", name, name)
+	}
+
+	sh.activateConnection(existing)
+	return nil
+}
+
+func (sh *Shell) getConnections() []shellcmd.ConnectionInfo {
+	connections := make([]shellcmd.ConnectionInfo, 0, len(sh.connections))
+	for _, conn := range sh.connections {
+		connections = append(connections, shellcmd.ConnectionInfo{Name: conn.name, Uri: conn.db.Uri, Active: conn == sh.activeConnection})
+	}
+	return connections
+}
+
+func (sh *Shell) getConnection(name string) *connection {
+	for _, conn := range sh.connections {
+		if conn.name == name {
+			return conn
+		}
+	}
+	return nil
+}
+
+// activateConnection routes statements and dot commands to the connection and switches to its history file
+func (sh *Shell) activateConnection(conn *connection) {
+	sh.activeConnection = conn
+	sh.db = conn.db
+	sh.dbCmdConfig.Db = conn.db
+	conn.db.SetParameters(sh.state.parameters)
+
+	sh.state.readline.SetHistoryPath(sh.getHistoryFile())
+	sh.state.readline.SetPrompt(sh.newStatementPrompt())
+}
+
+// Close closes the databases of every connection, which may not include the one the shell was created with
+func (sh *Shell) Close() {
+	for _, conn := range sh.connections {
+		sh.closeDb(conn.name, conn.db)
+	}
+}
+
+// closeDb rolls back the open transaction of the database, warning about it, before closing it
+func (sh *Shell) closeDb(connectionName string, database *db.Db) {
+	sh.rollbackOpenTransaction(connectionName, database)
+	database.Close()
+}
+
+// confirmQuit asks whether to commit or roll back the open transaction of each connection before quitting, so their
+// changes aren't discarded silently. It returns false if the user chose to keep the shell open
+func (sh *Shell) confirmQuit() bool {
+	sh.state.readline.HistoryDisable()
+	defer sh.state.readline.HistoryEnable()
+
+	for _, conn := range sh.connections {
+		if !conn.db.InTransaction() {
+			continue
+		}
+
+		fmt.Fprintf(sh.config.ErrF, "Warning: %s has an open transaction\n", sh.describeConnection(conn.name))
+		sh.state.readline.SetPrompt(sh.promptFmt("commit, rollback or cancel? "))
+		for conn.db.InTransaction() {
+			answer, err := sh.state.readline.Readline()
+			if err == io.EOF {
+				// nothing else can be read, so the transaction is rolled back when the shell stops
+				return true
+			}
+			if err != nil {
+				return false
+			}
+
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "commit":
+				_, err = conn.db.ExecuteStatementsDiscardingRows("COMMIT;")
+			case "rollback":
+				_, err = conn.db.ExecuteStatementsDiscardingRows("ROLLBACK;")
+			case "cancel":
+				return false
+			default:
+				fmt.Fprintln(sh.config.ErrF, "Answer commit, rollback or cancel")
+			}
+			if err != nil {
+				db.PrintError(err, sh.config.ErrF)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (sh *Shell) rollbackOpenTransactions() {
+	for _, conn := range sh.connections {
+		sh.rollbackOpenTransaction(conn.name, conn.db)
+	}
+}
+
+func (sh *Shell) rollbackOpenTransaction(connectionName string, database *db.Db) {
+	if !database.InTransaction() {
+		return
+	}
+
+	if _, err := database.ExecuteStatementsDiscardingRows("ROLLBACK;"); err != nil {
+		db.PrintError(err, sh.config.ErrF)
+		return
+	}
+	fmt.Fprintf(sh.config.ErrF, "Warning: the open transaction of %s was rolled back\n", sh.describeConnection(connectionName))
+}
+
+// describeConnection names the connection in messages, unless it's the only one
+func (sh *Shell) describeConnection(connectionName string) string {
+	if len(sh.connections) > 1 {
+		return "connection " + connectionName
+	}
+	return "the database"
+}
+
 func isStatementFinished(statement string) bool {
 	_, splitExtraInfos := sqliteparserutils.SplitStatement(statement)
 	return !splitExtraInfos.IncompleteCreateTriggerStatement &&
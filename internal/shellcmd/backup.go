@@ -0,0 +1,55 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   ".backup FILE",
+	Short: "Copy the database into a new local SQLite file",
+	Long: `Copy the schema and records of the database into a new local SQLite file. The database is read inside a single
+transaction when the connection supports it, and the number of rows of each table is verified at the end.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		progress, err := cmd.Flags().GetBool("progress")
+		if err != nil {
+			return err
+		}
+
+		return Backup(config, args[0], progress)
+	},
+}
+
+// Backup copies the database into a new local SQLite file, optionally reporting each copied table
+func Backup(config *DbCmdConfig, filePath string, progress bool) error {
+	options := db.CopyOptions{}
+	if progress {
+		options.Progress = func(tableName string, copiedRows int) {
+			fmt.Fprintf(config.ErrF, "copied table %s (%d rows)\n", tableName, copiedRows)
+		}
+	}
+
+	report, err := config.Db.BackupToFile(filePath, options)
+	if err != nil {
+		return err
+	}
+
+	if !report.Consistent {
+		fmt.Fprintln(config.ErrF, "Warning: the database could not be read in a single transaction, so the backup may not be consistent")
+	}
+	fmt.Fprintf(config.OutF, "Backup of %d tables and %d rows written to %s\n", len(report.Tables), report.TotalRows(), filePath)
+	return nil
+}
+
+func init() {
+	backupCmd.Flags().Bool("progress", false, "Report backup progress on stderr")
+}
@@ -0,0 +1,41 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var bailCmd = &cobra.Command{
+	Use:   ".bail on|off",
+	Short: "Stop at the first failing statement",
+	Long: `Stop executing the remaining statements of the input at the first one that fails, which is the default. With
+"off", every failing statement is reported with its position and text, the following ones are still executed and a
+summary is printed at the end.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		currentMode := "off"
+		if config.GetBail() {
+			currentMode = "on"
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("No mode provided. Current mode is %s. Valid modes are on, off", currentMode)
+		}
+
+		switch args[0] {
+		case "on":
+			config.SetBail(true)
+		case "off":
+			config.SetBail(false)
+		default:
+			return fmt.Errorf("Invalid mode. Current mode is %s. Valid modes are on, off", currentMode)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,78 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   ".clone URL_OR_PATH",
+	Short: "Copy the schema and records into another database",
+	Long: `Copy the schema and records of the current database into another local or remote database. Tables are created
+in foreign key dependency order and their records are inserted in batches of --batch-size rows. Use --truncate to drop
+the tables and views of the destination first.
+
+A local destination is written in a single transaction, so nothing is left behind when the copy fails. A remote
+destination commits each batch, so a failed copy leaves it partly written.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		authToken, err := cmd.Flags().GetString("auth")
+		if err != nil {
+			return err
+		}
+		options := db.CopyOptions{}
+		if options.Truncate, err = cmd.Flags().GetBool("truncate"); err != nil {
+			return err
+		}
+		if options.BatchSize, err = cmd.Flags().GetInt("batch-size"); err != nil {
+			return err
+		}
+		if options.BatchSize < 1 {
+			return fmt.Errorf("batch-size must be at least 1")
+		}
+		progress, err := cmd.Flags().GetBool("progress")
+		if err != nil {
+			return err
+		}
+		if progress {
+			options.Progress = func(tableName string, copiedRows int) {
+				fmt.Fprintf(config.ErrF, "copied table %s (%d rows)\n", tableName, copiedRows)
+			}
+		}
+
+		destination, err := db.NewDb(args[0], authToken, "", false, "")
+		if err != nil {
+			return err
+		}
+		defer destination.Close()
+		if err := destination.TestConnection(); err != nil {
+			return err
+		}
+
+		report, err := config.Db.CopyTo(destination, options)
+		if err != nil {
+			return err
+		}
+
+		if !report.Consistent {
+			fmt.Fprintln(config.ErrF, "Warning: the database could not be read in a single transaction, so the copy may not be consistent")
+		}
+		fmt.Fprintf(config.OutF, "Cloned %d tables and %d rows into %s\n", len(report.Tables), report.TotalRows(), args[0])
+		return nil
+	},
+}
+
+func init() {
+	cloneCmd.Flags().String("auth", "", "Auth token of the destination database")
+	cloneCmd.Flags().Bool("truncate", false, "Drop every table and view of the destination before copying")
+	cloneCmd.Flags().Int("batch-size", 100, "Number of rows inserted by each INSERT statement")
+	cloneCmd.Flags().Bool("progress", false, "Report clone progress on stderr")
+}
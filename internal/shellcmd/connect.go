@@ -0,0 +1,76 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+type ConnectionInfo struct {
+	Name   string
+	Uri    string
+	Active bool
+}
+
+var connectCmd = &cobra.Command{
+	Use:   ".connect NAME [URL_OR_PATH]",
+	Short: "Open a named connection or switch to it",
+	Long: `With URL_OR_PATH, open the database as a connection called NAME and make it the active one. A connection that
+already has that name is closed and replaced. Without URL_OR_PATH, switch to the already opened connection NAME.
+The first database of the shell is the "default" connection.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		name := args[0]
+		if len(args) == 1 {
+			return config.UseConnection(name)
+		}
+
+		authToken, err := cmd.Flags().GetString("auth")
+		if err != nil {
+			return err
+		}
+
+		newDb, err := openAndTestDb(config.Db, args[1], authToken)
+		if err != nil {
+			return err
+		}
+
+		config.OpenConnection(name, newDb)
+		return nil
+	},
+}
+
+var connectionsCmd = &cobra.Command{
+	Use:   ".connections",
+	Short: "List the opened connections",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		data := [][]string{}
+		for _, connection := range config.GetConnections() {
+			active := ""
+			if connection.Active {
+				active = "*"
+			}
+			data = append(data, []string{active, connection.Name, connection.Uri})
+		}
+
+		db.PrintTable(config.OutF, []string{"active", "name", "database"}, data)
+		return nil
+	},
+}
+
+func init() {
+	connectCmd.Flags().String("auth", "", "Auth token of the database")
+}
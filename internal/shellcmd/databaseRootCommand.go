@@ -6,6 +6,7 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/libsql/libsql-shell-go/internal/db"
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
@@ -14,12 +15,35 @@ import (
 type dbCtx struct{}
 
 type DbCmdConfig struct {
+	// InF is the input of the shell, which commands running interactive programs hand over to them
+	InF               io.Reader
 	OutF              io.Writer
 	ErrF              io.Writer
 	Db                *db.Db
 	SetInterruptShell func()
 	SetMode           func(mode enums.PrintMode)
 	GetMode           func() enums.PrintMode
+	SetDb             func(newDb *db.Db)
+	OpenConnection    func(name string, newDb *db.Db)
+	UseConnection     func(name string) error
+	GetConnections    func() []ConnectionInfo
+	SetEqpMode        func(mode EqpMode)
+	GetEqpMode        func() EqpMode
+	SetBail           func(bail bool)
+	GetBail           func() bool
+	SetEcho           func(echo bool)
+	GetEcho           func() bool
+	GetParameters     func() db.Parameters
+	// GetCommandLine returns the line of the command being executed, as it was typed
+	GetCommandLine    func() string
+	SetPromptTemplate func(template string)
+	GetPromptTemplate func() string
+	// ExecuteScript runs the lines of a file as if they were typed in the shell
+	ExecuteScript func(path string) error
+	// GetEditableStatements returns the statement being typed, or else the statements typed last
+	GetEditableStatements func() string
+	// ExecuteEditedStatements executes statements as if they were typed in the shell, saving them in history
+	ExecuteEditedStatements func(statements string)
 }
 
 const helpTemplate = `{{range .Commands}}{{if (and (not .Hidden) (or .IsAvailableCommand) (ne .Name "completion"))}}
@@ -39,7 +63,7 @@ func NewDatabaseRootCmd(config *DbCmdConfig) *cobra.Command {
 		},
 	}
 
-	rootCmd.AddCommand(tableCmd, schemaCmd, helpCmd, readCmd, indexesCmd, quitCmd, dumpCmd, modeCmd)
+	rootCmd.AddCommand(tableCmd, schemaCmd, helpCmd, readCmd, indexesCmd, quitCmd, dumpCmd, modeCmd, restoreCmd, backupCmd, cloneCmd, openCmd, connectCmd, connectionsCmd, databasesCmd, describeCmd, erdCmd, explainCmd, eqpCmd, statsCmd, lintCmd, schemaDiffCmd, migrateCmd, bailCmd, echoCmd, paramCmd, promptCmd, txCmd, editCmd)
 	rootCmd.SetOut(config.OutF)
 	rootCmd.SetErr(config.ErrF)
 	rootCmd.SetHelpTemplate(helpTemplate)
@@ -49,3 +73,17 @@ func NewDatabaseRootCmd(config *DbCmdConfig) *cobra.Command {
 func CreateNewDatabaseRootCmd(config *DbCmdConfig) *cobra.Command {
 	return NewDatabaseRootCmd(config)
 }
+
+// ResetFlags restores the default value of every flag of the command tree.
+// Dot commands are package level, so flag values would otherwise leak from one execution to the next
+func ResetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+	for _, subCmd := range cmd.Commands() {
+		ResetFlags(subCmd)
+	}
+}
@@ -0,0 +1,95 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
+)
+
+var databasesCmd = &cobra.Command{
+	Use:   ".databases",
+	Short: "List the main and attached databases",
+	Long:  `List the main database and the ones attached to it with "ATTACH DATABASE 'file' AS name".`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		return config.Db.ExecuteAndPrintStatements("SELECT name, file FROM pragma_database_list ORDER BY seq", config.OutF, false, enums.TABLE_MODE)
+	},
+}
+
+// schemaPattern is the "schema.name" pattern accepted by the schema commands.
+// Without a known schema prefix, the name is looked up in every database
+type schemaPattern struct {
+	schemas     []string
+	namePattern string
+}
+
+func parseSchemaPattern(config *DbCmdConfig, args []string) (schemaPattern, error) {
+	schemaNames, err := config.Db.GetSchemaNames()
+	if err != nil {
+		return schemaPattern{}, err
+	}
+
+	pattern := schemaPattern{schemas: schemaNames}
+	if len(args) == 0 {
+		return pattern, nil
+	}
+
+	pattern.namePattern = args[0]
+	if schema, name, found := strings.Cut(args[0], "."); found {
+		for _, schemaName := range schemaNames {
+			if strings.EqualFold(schemaName, schema) {
+				return schemaPattern{schemas: []string{schemaName}, namePattern: name}, nil
+			}
+		}
+	}
+	return pattern, nil
+}
+
+// nameFilter returns the condition matching the column against the name pattern, if there is one
+func (p schemaPattern) nameFilter(column string) string {
+	if p.namePattern == "" {
+		return ""
+	}
+	return fmt.Sprintf(" and %s like '%s'", column, db.EscapeSingleQuotes(p.namePattern))
+}
+
+// schemaTable returns the sqlite_schema table of the given database
+func schemaTable(schema string) string {
+	return db.QuoteIdentifier(schema) + ".sqlite_schema"
+}
+
+// qualifiedName prefixes the column with the schema name for objects outside of main
+func qualifiedName(schema string, column string) string {
+	if schema == "main" {
+		return column
+	}
+	return fmt.Sprintf("'%s.' || %s", db.EscapeSingleQuotes(schema), column)
+}
+
+// parseTableName splits a "schema.table" name. Without a known schema prefix, the table belongs to main
+func parseTableName(config *DbCmdConfig, name string) (schema string, tableName string, err error) {
+	schema, tableName, found := strings.Cut(name, ".")
+	if !found {
+		return "main", name, nil
+	}
+
+	schemaNames, err := config.Db.GetSchemaNames()
+	if err != nil {
+		return "", "", err
+	}
+	for _, schemaName := range schemaNames {
+		if strings.EqualFold(schemaName, schema) {
+			return schemaName, tableName, nil
+		}
+	}
+	return "main", name, nil
+}
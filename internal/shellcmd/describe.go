@@ -0,0 +1,56 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   ".describe TABLE",
+	Short: "Describe the columns of a table",
+	Long: `Describe the columns of a table with their type, constraints, default value, foreign keys and the indexes covering them.
+Hidden and generated columns are included. TABLE can be given as schema.table for attached databases and the description
+follows the current output mode.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		schema, tableName, err := parseTableName(config, args[0])
+		if err != nil {
+			return err
+		}
+
+		columns, err := config.Db.DescribeTable(schema, tableName)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]interface{}, 0, len(columns))
+		for _, column := range columns {
+			var defaultValue interface{}
+			if column.DefaultValue.Valid {
+				defaultValue = column.DefaultValue.String
+			}
+			rows = append(rows, []interface{}{
+				column.Name,
+				column.Type,
+				column.NotNull,
+				defaultValue,
+				column.PrimaryKey,
+				column.Kind.String(),
+				strings.Join(column.ForeignKeys, ", "),
+				strings.Join(column.Indexes, ", "),
+			})
+		}
+
+		header := []string{"name", "type", "not_null", "default", "primary_key", "kind", "references", "indexes"}
+		return db.PrintRows(header, rows, config.OutF, false, config.GetMode())
+	},
+}
@@ -2,10 +2,15 @@ package shellcmd
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,8 +18,28 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const defaultDumpTimeout = 30 * time.Second
+
+const dumpManifestFileName = "manifest.json"
+
+type dumpOptions struct {
+	timeout       time.Duration
+	progress      bool
+	useStatements bool
+	rowsPerInsert int
+	gzip          bool
+	outputFile    string
+	outputDir     string
+}
+
+// mustUseStatements reports if the options need control over the generated statements,
+// which the remote /dump endpoint does not provide
+func (o dumpOptions) mustUseStatements() bool {
+	return o.useStatements || o.rowsPerInsert > 1 || o.outputDir != ""
+}
+
 var dumpCmd = &cobra.Command{
-	Use:   ".dump",
+	Use:   ".dump [--output FILE | --dir DIR] [--gzip] [--rows-per-insert N]",
 	Short: "Render database content as SQL",
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -23,28 +48,273 @@ var dumpCmd = &cobra.Command{
 			return fmt.Errorf("missing db connection")
 		}
 
-		if config.Db.IsRemote() {
-			return dumpRemote(cmd.Context(), config)
-		} else {
-			return dumpLocal(config)
+		options, err := getDumpOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		progress := newDumpProgress(config.ErrF, options.progress)
+		defer progress.finish()
+
+		if options.outputDir != "" {
+			return dumpToDirectory(config, options, progress)
 		}
+
+		out, err := openDumpOutput(config.OutF, options.outputFile, options.gzip)
+		if err != nil {
+			return err
+		}
+
+		err = dump(cmd.Context(), out, config, options, progress)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		return err
 	},
 }
 
-func dumpLocal(config *DbCmdConfig) error {
-	fmt.Fprintln(config.OutF, "PRAGMA foreign_keys=OFF;")
-	fmt.Fprintln(config.OutF, "BEGIN TRANSACTION;")
+func init() {
+	dumpCmd.Flags().Duration("timeout", defaultDumpTimeout, "Maximum time to wait for the remote /dump endpoint. 0 disables the timeout")
+	dumpCmd.Flags().Bool("progress", false, "Report dump progress on stderr")
+	dumpCmd.Flags().Bool("statements", false, "Skip the remote /dump endpoint and dump using SQL statements")
+	dumpCmd.Flags().Int("rows-per-insert", 1, "Number of rows written by each INSERT statement")
+	dumpCmd.Flags().Bool("gzip", false, "Compress the dump with gzip")
+	dumpCmd.Flags().String("output", "", "Write the dump to FILE instead of the standard output")
+	dumpCmd.Flags().String("dir", "", "Write one file per table and a "+dumpManifestFileName+" into DIR")
+}
+
+func getDumpOptions(cmd *cobra.Command) (dumpOptions, error) {
+	var options dumpOptions
+	var err error
+
+	if options.timeout, err = cmd.Flags().GetDuration("timeout"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.timeout < 0 {
+		return dumpOptions{}, fmt.Errorf("timeout must not be negative")
+	}
+	if options.progress, err = cmd.Flags().GetBool("progress"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.useStatements, err = cmd.Flags().GetBool("statements"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.rowsPerInsert, err = cmd.Flags().GetInt("rows-per-insert"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.rowsPerInsert < 1 {
+		return dumpOptions{}, fmt.Errorf("rows-per-insert must be at least 1")
+	}
+	if options.gzip, err = cmd.Flags().GetBool("gzip"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.outputFile, err = cmd.Flags().GetString("output"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.outputDir, err = cmd.Flags().GetString("dir"); err != nil {
+		return dumpOptions{}, err
+	}
+	if options.outputFile != "" && options.outputDir != "" {
+		return dumpOptions{}, fmt.Errorf("--output and --dir can't be used together")
+	}
+	if options.gzip && options.outputFile == "" && options.outputDir == "" {
+		// compressed data would be printed to the terminal
+		return dumpOptions{}, fmt.Errorf("--gzip needs --output or --dir")
+	}
+
+	return options, nil
+}
+
+func dump(ctx context.Context, out io.Writer, config *DbCmdConfig, options dumpOptions, progress *dumpProgress) error {
+	if !config.Db.IsRemote() || options.mustUseStatements() {
+		return dumpStatements(out, config, options, progress)
+	}
+
+	err := dumpRemote(ctx, out, config, options.timeout, progress)
+	if _, endpointUnavailable := err.(*dumpEndpointUnavailableError); endpointUnavailable {
+		progress.report("%s. Falling back to a statement based dump\n", err)
+		return dumpStatements(out, config, options, progress)
+	}
+	return err
+}
+
+// dumpOutput buffers and optionally compresses everything written to the underlying writer
+type dumpOutput struct {
+	*bufio.Writer
+	closers []io.Closer
+}
 
-	getTableNamesStatementResult, err := getDbTableNames(config)
+func (o *dumpOutput) Close() error {
+	err := o.Flush()
+	for _, closer := range o.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func openDumpOutput(defaultOut io.Writer, filePath string, compress bool) (*dumpOutput, error) {
+	out := &dumpOutput{}
+
+	var writer = defaultOut
+	if filePath != "" {
+		file, err := os.Create(filePath)
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+		out.closers = append(out.closers, file)
+	}
+
+	if compress {
+		gzipWriter := gzip.NewWriter(writer)
+		writer = gzipWriter
+		// the gzip writer must be closed before the file it writes to
+		out.closers = append([]io.Closer{gzipWriter}, out.closers...)
+	}
+
+	out.Writer = bufio.NewWriter(writer)
+	return out, nil
+}
+
+type dumpManifest struct {
+	Version       int                 `json:"version"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Compression   string              `json:"compression"`
+	RowsPerInsert int                 `json:"rows_per_insert"`
+	Tables        []dumpManifestTable `json:"tables"`
+}
+
+type dumpManifestTable struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int    `json:"rows"`
+}
+
+func dumpToDirectory(config *DbCmdConfig, options dumpOptions, progress *dumpProgress) error {
+	if err := os.MkdirAll(options.outputDir, 0o755); err != nil {
+		return err
+	}
+
+	tableNames, err := getDbTableNames(config)
 	if err != nil {
 		return err
 	}
 
-	err = dumpTables(getTableNamesStatementResult, config)
+	manifest := dumpManifest{
+		Version:       1,
+		CreatedAt:     time.Now().UTC(),
+		Compression:   "none",
+		RowsPerInsert: options.rowsPerInsert,
+		Tables:        make([]dumpManifestTable, 0, len(tableNames)),
+	}
+	if options.gzip {
+		manifest.Compression = "gzip"
+	}
+
+	for i, tableName := range tableNames {
+		fileName := getTableDumpFileName(i, tableName, options.gzip)
+		out, err := openDumpOutput(nil, filepath.Join(options.outputDir, fileName), options.gzip)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(out, "PRAGMA foreign_keys=OFF;")
+		fmt.Fprintln(out, "BEGIN TRANSACTION;")
+		rowCount, err := dumpTable(out, tableName, config, options, progress)
+		if err == nil {
+			fmt.Fprintln(out, "COMMIT;")
+		}
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		manifest.Tables = append(manifest.Tables, dumpManifestTable{Name: tableName, File: fileName, Rows: rowCount})
+	}
+
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(config.OutF, "COMMIT;")
+	return os.WriteFile(filepath.Join(options.outputDir, dumpManifestFileName), append(manifestContent, '\n'), 0o644)
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+
+// getTableDumpFileName prefixes the file with the table position, keeping names unique and
+// in dump order even when sanitizing the table name makes two of them equal
+func getTableDumpFileName(position int, tableName string, compress bool) string {
+	fileName := fmt.Sprintf("%04d_%s.sql", position+1, unsafeFileNameCharacters.ReplaceAllString(tableName, "_"))
+	if compress {
+		fileName += ".gz"
+	}
+	return fileName
+}
+
+type dumpEndpointUnavailableError struct {
+	reason string
+}
+
+func (e *dumpEndpointUnavailableError) Error() string {
+	return "remote /dump endpoint unavailable: " + e.reason
+}
+
+type dumpProgress struct {
+	errF    io.Writer
+	enabled bool
+
+	reportedBytes int64
+}
+
+const dumpProgressBytesStep = 1 << 20
+
+func newDumpProgress(errF io.Writer, enabled bool) *dumpProgress {
+	return &dumpProgress{errF: errF, enabled: enabled}
+}
+
+func (p *dumpProgress) report(format string, args ...interface{}) {
+	if p.enabled {
+		fmt.Fprintf(p.errF, format, args...)
+	}
+}
+
+func (p *dumpProgress) tableDumped(tableName string, rowCount int) {
+	p.report("dumped table %s (%d rows)\n", tableName, rowCount)
+}
+
+func (p *dumpProgress) bytesReceived(total int64) {
+	if total-p.reportedBytes >= dumpProgressBytesStep {
+		p.reportedBytes = total
+		p.report("\rreceived %.1f MiB", float64(total)/float64(1<<20))
+	}
+}
+
+func (p *dumpProgress) finish() {
+	if p.reportedBytes > 0 {
+		p.report("\n")
+		p.reportedBytes = 0
+	}
+}
+
+func dumpStatements(out io.Writer, config *DbCmdConfig, options dumpOptions, progress *dumpProgress) error {
+	fmt.Fprintln(out, "PRAGMA foreign_keys=OFF;")
+	fmt.Fprintln(out, "BEGIN TRANSACTION;")
+
+	tableNames, err := getDbTableNames(config)
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range tableNames {
+		if _, err = dumpTable(out, tableName, config, options, progress); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(out, "COMMIT;")
 	return nil
 }
 
@@ -52,108 +322,142 @@ func getDbURLForDump(u string) string {
 	if strings.HasPrefix(u, "wss://") || strings.HasPrefix(u, "ws://") {
 		return strings.Replace(u, "ws", "http", 1)
 	}
+	if strings.HasPrefix(u, "libsql://") {
+		return strings.Replace(u, "libsql", "https", 1)
+	}
 	return u
 }
 
-func dumpRemote(ctx context.Context, config *DbCmdConfig) error {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+func dumpRemote(ctx context.Context, out io.Writer, config *DbCmdConfig, timeout time.Duration, progress *dumpProgress) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	req, err := http.NewRequestWithContext(ctx, "GET", getDbURLForDump(config.Db.Uri+"/dump"), nil)
 	if err != nil {
-		return err
+		return &dumpEndpointUnavailableError{reason: err.Error()}
 	}
 	req.Header.Add("Authorization", "Bearer "+config.Db.AuthToken)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return &dumpEndpointUnavailableError{reason: err.Error()}
 	}
 	defer resp.Body.Close()
 
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		// servers without the endpoint
+		return &dumpEndpointUnavailableError{reason: resp.Status}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("remote /dump endpoint refused the credentials: %s. Check the auth token", resp.Status)
+	default:
+		return fmt.Errorf("remote /dump endpoint failed: %s", resp.Status)
+	}
+
+	var receivedBytes int64
 	reader := bufio.NewReader(resp.Body)
 	for err != io.EOF {
 		var line string
 		if line, err = reader.ReadString('\n'); err != nil && err != io.EOF {
 			return err
 		}
-		fmt.Fprint(config.OutF, line)
+		fmt.Fprint(out, line)
+
+		receivedBytes += int64(len(line))
+		progress.bytesReceived(receivedBytes)
 	}
 	return nil
 }
 
-func dumpTables(getTableStatementResult db.StatementResult, config *DbCmdConfig) error {
-	for tableNameRowResult := range getTableStatementResult.RowCh {
-		if tableNameRowResult.Err != nil {
-			return tableNameRowResult.Err
-		}
-		formattedRow, err := db.FormatData(tableNameRowResult.Row, db.TABLE)
-		if err != nil {
-			return err
-		}
+func dumpTable(out io.Writer, tableName string, config *DbCmdConfig, options dumpOptions, progress *dumpProgress) (rowCount int, err error) {
+	createTableStmt, otherStmts, err := getTableSchema(config, tableName)
+	if err != nil {
+		return 0, err
+	}
 
-		formattedTableName := formattedRow[0]
+	fmt.Fprintln(out, createTableStmt)
 
-		createTableStmt, otherStmts, err := getTableSchema(config, formattedTableName)
-		if err != nil {
-			return err
-		}
+	tableRecordsStatementResult, err := getTableRecords(config, tableName)
+	if err != nil {
+		return 0, err
+	}
 
-		fmt.Fprintln(config.OutF, createTableStmt)
+	rowCount, err = dumpTableRecords(out, tableRecordsStatementResult, tableName, options.rowsPerInsert)
+	if err != nil {
+		return rowCount, err
+	}
+	progress.tableDumped(tableName, rowCount)
 
-		tableRecordsStatementResult, err := getTableRecords(config, formattedTableName)
-		if err != nil {
-			return err
-		}
+	for _, stmt := range otherStmts {
+		fmt.Fprintln(out, stmt)
+	}
 
-		err = dumpTableRecords(tableRecordsStatementResult, config, formattedTableName)
-		if err != nil {
-			return err
-		}
+	return rowCount, nil
+}
 
-		for _, stmt := range otherStmts {
-			fmt.Fprintln(config.OutF, stmt)
-		}
+func dumpTableRecords(out io.Writer, tableRecordsStatementResult db.StatementResult, tableName string, rowsPerInsert int) (rowCount int, err error) {
+	var formattedTableName = tableName
+	if db.NeedsEscaping(tableName) {
+		formattedTableName = "\"" + db.EscapeSingleQuotes(tableName) + "\""
 	}
 
-	return nil
-}
+	pendingValues := make([]string, 0, rowsPerInsert)
+	flushPendingValues := func() {
+		if len(pendingValues) == 0 {
+			return
+		}
+		fmt.Fprintln(out, "INSERT INTO "+formattedTableName+" VALUES"+strings.Join(pendingValues, ",")+";")
+		pendingValues = pendingValues[:0]
+	}
 
-func dumpTableRecords(tableRecordsStatementResult db.StatementResult, config *DbCmdConfig, tableName string) error {
 	for tableRecordsRowResult := range tableRecordsStatementResult.RowCh {
 		if tableRecordsRowResult.Err != nil {
-			return tableRecordsRowResult.Err
+			return rowCount, tableRecordsRowResult.Err
 		}
 
-		var formattedTableName = tableName
-		if db.NeedsEscaping(tableName) {
-			formattedTableName = "\"" + db.EscapeSingleQuotes(tableName) + "\""
-		}
-		insertStatement := "INSERT INTO " + formattedTableName + " VALUES("
-
 		tableRecordsFormattedRow, err := db.FormatData(tableRecordsRowResult.Row, db.SQLITE)
 		if err != nil {
-			return err
+			return rowCount, err
 		}
 
-		insertStatement += strings.Join(tableRecordsFormattedRow, ",")
-		insertStatement += ");"
-		fmt.Fprintln(config.OutF, insertStatement)
+		pendingValues = append(pendingValues, "("+strings.Join(tableRecordsFormattedRow, ",")+")")
+		rowCount++
+		if len(pendingValues) == rowsPerInsert {
+			flushPendingValues()
+		}
 	}
+	flushPendingValues()
 
-	return nil
+	return rowCount, nil
 }
 
-func getDbTableNames(config *DbCmdConfig) (db.StatementResult, error) {
+// getDbTableNames reads every table name before the dump starts so no other query runs while the result is open
+func getDbTableNames(config *DbCmdConfig) ([]string, error) {
 	listTablesResult, err := config.Db.ExecuteStatements("SELECT name FROM sqlite_master WHERE type='table' and name not like 'sqlite_%' and name != '_litestream_seq' and name != '_litestream_lock'")
 	if err != nil {
-		return db.StatementResult{}, err
+		return nil, err
 	}
 
 	statementResult := <-listTablesResult.StatementResultCh
 	if statementResult.Err != nil {
-		return db.StatementResult{}, statementResult.Err
+		return nil, statementResult.Err
 	}
 
-	return statementResult, nil
+	tableNames := make([]string, 0)
+	for tableNameRowResult := range statementResult.RowCh {
+		if tableNameRowResult.Err != nil {
+			return nil, tableNameRowResult.Err
+		}
+		formattedRow, err := db.FormatData(tableNameRowResult.Row, db.TABLE)
+		if err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, formattedRow[0])
+	}
+
+	return tableNames, nil
 }
 
 func getTableSchema(config *DbCmdConfig, tableName string) (createTable string, otherStmts []string, err error) {
@@ -0,0 +1,40 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var echoCmd = &cobra.Command{
+	Use:   ".echo on|off",
+	Short: "Print each statement before its results",
+	Long: `Print each statement before its results, so the result sets of several statements can be told apart. In json
+mode, the rows of each statement are printed as {"statement": ..., "rows": [...]} instead.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		currentMode := "off"
+		if config.GetEcho() {
+			currentMode = "on"
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("No mode provided. Current mode is %s. Valid modes are on, off", currentMode)
+		}
+
+		switch args[0] {
+		case "on":
+			config.SetEcho(true)
+		case "off":
+			config.SetEcho(false)
+		default:
+			return fmt.Errorf("Invalid mode. Current mode is %s. Valid modes are on, off", currentMode)
+		}
+		return nil
+	},
+}
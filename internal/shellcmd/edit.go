@@ -0,0 +1,75 @@
+package shellcmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultEditor = "vi"
+
+var editCmd = &cobra.Command{
+	Use:   ".edit",
+	Short: "Write the current or last statement in $EDITOR and execute it",
+	Long: `Open the statement being typed, or else the last statements executed, in the editor set by $EDITOR (vi if it
+isn't set). Once the editor is closed, the saved statements are executed and added to the history as a single entry,
+unless a string inside them spans several lines. Nothing is executed if the file is left empty.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		statements, err := editInEditor(config, config.GetEditableStatements())
+		if err != nil {
+			return err
+		}
+
+		config.ExecuteEditedStatements(statements)
+		return nil
+	},
+}
+
+// editInEditor writes the text in a temporary file, opens it in the editor on the streams of the shell and returns the
+// saved content
+func editInEditor(config *DbCmdConfig, text string) (string, error) {
+	file, err := os.CreateTemp("", "libsql-shell-*.sql")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if text != "" {
+		text = strings.TrimSpace(text) + "\n"
+	}
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the editor may be given with arguments, like "code --wait"
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{defaultEditor}
+	}
+	editorCmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	editorCmd.Stdin = config.InF
+	editorCmd.Stdout = config.OutF
+	editorCmd.Stderr = config.ErrF
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run the editor %s: %w", editor[0], err)
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
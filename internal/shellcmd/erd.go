@@ -0,0 +1,243 @@
+package shellcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+const (
+	erdMermaidFormat = "mermaid"
+	erdDotFormat     = "dot"
+)
+
+var erdCmd = &cobra.Command{
+	Use:   ".erd [--format mermaid|dot] [--tables PATTERN] [FILE]",
+	Short: "Export an entity relationship diagram of the schema",
+	Long: `Export the tables, columns and foreign keys of the database as a Mermaid or Graphviz DOT diagram, written to FILE
+or to the standard output. With --tables, only the tables matching the LIKE pattern and the tables they reference or are
+referenced by are included.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		var writeDiagram func(out io.Writer, tables []erdTable)
+		switch format {
+		case erdMermaidFormat:
+			writeDiagram = writeMermaidDiagram
+		case erdDotFormat:
+			writeDiagram = writeDotDiagram
+		default:
+			return fmt.Errorf("unsupported diagram format: %s. Use %s or %s", format, erdMermaidFormat, erdDotFormat)
+		}
+
+		pattern, err := cmd.Flags().GetString("tables")
+		if err != nil {
+			return err
+		}
+
+		tables, err := getErdTables(config.Db, pattern)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			writeDiagram(config.OutF, tables)
+			return nil
+		}
+
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		writeDiagram(file, tables)
+		return file.Close()
+	},
+}
+
+func init() {
+	erdCmd.Flags().String("format", erdMermaidFormat, "Diagram format: mermaid or dot")
+	erdCmd.Flags().String("tables", "", "Only include the tables matching the LIKE pattern and their neighbors")
+}
+
+type erdTable struct {
+	name        string
+	columns     []db.ColumnDescription
+	foreignKeys []db.ForeignKey
+}
+
+func getErdTables(database *db.Db, pattern string) ([]erdTable, error) {
+	tableNames, err := database.GetTableNames("main", "")
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys := make(map[string][]db.ForeignKey, len(tableNames))
+	for _, tableName := range tableNames {
+		if foreignKeys[tableName], err = database.GetForeignKeys("main", tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	// SQLite table names are case insensitive, so are the references of foreign keys
+	included := make(map[string]bool, len(tableNames))
+	if pattern == "" {
+		for _, tableName := range tableNames {
+			included[strings.ToLower(tableName)] = true
+		}
+	} else {
+		matchedNames, err := database.GetTableNames("main", pattern)
+		if err != nil {
+			return nil, err
+		}
+		matched := make(map[string]bool, len(matchedNames))
+		for _, tableName := range matchedNames {
+			matched[strings.ToLower(tableName)] = true
+			included[strings.ToLower(tableName)] = true
+		}
+		for _, tableName := range tableNames {
+			for _, foreignKey := range foreignKeys[tableName] {
+				if matched[strings.ToLower(tableName)] {
+					included[strings.ToLower(foreignKey.ReferencedTable)] = true
+				}
+				if matched[strings.ToLower(foreignKey.ReferencedTable)] {
+					included[strings.ToLower(tableName)] = true
+				}
+			}
+		}
+	}
+
+	tables := make([]erdTable, 0, len(included))
+	for _, tableName := range tableNames {
+		if !included[strings.ToLower(tableName)] {
+			continue
+		}
+
+		columns, err := database.DescribeTable("main", tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		table := erdTable{name: tableName, columns: columns}
+		for _, foreignKey := range foreignKeys[tableName] {
+			if included[strings.ToLower(foreignKey.ReferencedTable)] {
+				table.foreignKeys = append(table.foreignKeys, foreignKey)
+			}
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// getColumnKeys returns the PK/FK markers of a column
+func getColumnKeys(column db.ColumnDescription) []string {
+	keys := make([]string, 0, 2)
+	if column.PrimaryKey > 0 {
+		keys = append(keys, "PK")
+	}
+	if len(column.ForeignKeys) > 0 {
+		keys = append(keys, "FK")
+	}
+	return keys
+}
+
+// isOptionalForeignKey reports if any column of the foreign key accepts NULL, so a row may reference nothing
+func isOptionalForeignKey(table erdTable, foreignKey db.ForeignKey) bool {
+	for _, columnName := range foreignKey.Columns {
+		for _, column := range table.columns {
+			if strings.EqualFold(column.Name, columnName) && !column.NotNull && column.PrimaryKey == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var mermaidInvalidCharacters = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func mermaidWord(value string) string {
+	return mermaidInvalidCharacters.ReplaceAllString(value, "_")
+}
+
+func mermaidEntity(tableName string) string {
+	if db.NeedsEscaping(tableName) {
+		return "\"" + strings.ReplaceAll(tableName, "\"", "'") + "\""
+	}
+	return tableName
+}
+
+func writeMermaidDiagram(out io.Writer, tables []erdTable) {
+	fmt.Fprintln(out, "erDiagram")
+	for _, table := range tables {
+		fmt.Fprintf(out, "    %s {\n", mermaidEntity(table.name))
+		for _, column := range table.columns {
+			columnType := mermaidWord(column.Type)
+			if columnType == "" || columnType == "_" {
+				columnType = "ANY"
+			}
+			line := fmt.Sprintf("        %s %s", columnType, mermaidWord(column.Name))
+			if keys := getColumnKeys(column); len(keys) > 0 {
+				line += " " + strings.Join(keys, ", ")
+			}
+			fmt.Fprintln(out, line)
+		}
+		fmt.Fprintln(out, "    }")
+	}
+
+	for _, table := range tables {
+		for _, foreignKey := range table.foreignKeys {
+			referencedCardinality := "||"
+			if isOptionalForeignKey(table, foreignKey) {
+				referencedCardinality = "|o"
+			}
+			fmt.Fprintf(out, "    %s %s--o{ %s : \"%s\"\n", mermaidEntity(foreignKey.ReferencedTable), referencedCardinality,
+				mermaidEntity(table.name), strings.ReplaceAll(strings.Join(foreignKey.Columns, ", "), "\"", "'"))
+		}
+	}
+}
+
+var dotRecordSpecialCharacters = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+
+func dotId(value string) string {
+	return "\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + "\""
+}
+
+func writeDotDiagram(out io.Writer, tables []erdTable) {
+	fmt.Fprintln(out, "digraph schema {")
+	fmt.Fprintln(out, "    rankdir=LR;")
+	fmt.Fprintln(out, "    node [shape=record];")
+	for _, table := range tables {
+		var label strings.Builder
+		label.WriteString("{" + dotRecordSpecialCharacters.Replace(table.name) + "|")
+		for _, column := range table.columns {
+			field := strings.TrimSpace(column.Name + " " + column.Type)
+			if keys := getColumnKeys(column); len(keys) > 0 {
+				field += " (" + strings.Join(keys, ", ") + ")"
+			}
+			label.WriteString(dotRecordSpecialCharacters.Replace(field) + `\l`)
+		}
+		label.WriteString("}")
+		fmt.Fprintf(out, "    %s [label=\"%s\"];\n", dotId(table.name), label.String())
+	}
+
+	for _, table := range tables {
+		for _, foreignKey := range table.foreignKeys {
+			fmt.Fprintf(out, "    %s -> %s [label=%s];\n", dotId(table.name), dotId(foreignKey.ReferencedTable), dotId(strings.Join(foreignKey.Columns, ", ")))
+		}
+	}
+	fmt.Fprintln(out, "}")
+}
@@ -0,0 +1,144 @@
+package shellcmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+	"github.com/tursodatabase/libsql-client-go/sqliteparserutils"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
+)
+
+type EqpMode string
+
+const (
+	EqpOff  EqpMode = "off"
+	EqpOn   EqpMode = "on"
+	EqpFull EqpMode = "full"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   ".explain [--bytecode] STATEMENT",
+	Short: "Show the query plan of a statement",
+	Long: `Show the query plan of a statement as a tree, without running it. With --bytecode, show the instructions of the
+statement instead, with the body of loops indented.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		bytecode, err := cmd.Flags().GetBool("bytecode")
+		if err != nil {
+			return err
+		}
+
+		// the statement is taken from the line, since splitting it in arguments loses the spaces inside quoted strings
+		statement := remainderAfterFields(config.GetCommandLine(), len(strings.Fields(config.GetCommandLine()))-len(args))
+		statements, _ := sqliteparserutils.SplitStatement(statement)
+		for _, statement := range statements {
+			if bytecode {
+				err = printBytecode(config.Db, config.OutF, statement, config.GetMode())
+			} else {
+				err = printQueryPlan(config.Db, config.OutF, statement)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var eqpCmd = &cobra.Command{
+	Use:       ".eqp on|off|full",
+	Short:     "Show the query plan before the results of each statement",
+	Long:      `Show the query plan before the results of each statement. "full" also shows the bytecode of the statement.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{string(EqpOn), string(EqpOff), string(EqpFull)},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		validModes := strings.Join(cmd.ValidArgs, ", ")
+		if len(args) == 0 {
+			return fmt.Errorf("No mode provided. Current mode is %s. Valid modes are %s", config.GetEqpMode(), validModes)
+		}
+
+		switch mode := EqpMode(args[0]); mode {
+		case EqpOn, EqpOff, EqpFull:
+			config.SetEqpMode(mode)
+		default:
+			return fmt.Errorf("Invalid mode. Current mode is %s. Valid modes are %s", config.GetEqpMode(), validModes)
+		}
+		return nil
+	},
+}
+
+func init() {
+	explainCmd.Flags().Bool("bytecode", false, "Show the bytecode instead of the query plan")
+	// flags are only read before the statement, so it can contain values like -1
+	explainCmd.Flags().SetInterspersed(false)
+}
+
+// PrintAutomaticExplanation prints what ".eqp" asks for before the statement is executed.
+// Statements that can't be explained are skipped, since executing them reports the error
+func PrintAutomaticExplanation(database *db.Db, outF io.Writer, statement string, eqpMode EqpMode, printMode enums.PrintMode) {
+	if eqpMode == EqpOff || getFirstTokenType(statement) == sqliteparser.SQLiteLexerEXPLAIN_ {
+		return
+	}
+
+	_ = printQueryPlan(database, outF, statement)
+	if eqpMode == EqpFull {
+		_ = printBytecode(database, outF, statement, printMode)
+	}
+}
+
+func printQueryPlan(database *db.Db, outF io.Writer, statement string) error {
+	steps, err := database.GetQueryPlan(statement)
+	if err != nil {
+		return err
+	}
+	if len(steps) > 0 {
+		fmt.Fprint(outF, db.FormatQueryPlan(steps))
+	}
+	return nil
+}
+
+func printBytecode(database *db.Db, outF io.Writer, statement string, printMode enums.PrintMode) error {
+	instructions, err := database.GetBytecode(statement)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(instructions))
+	for _, instruction := range instructions {
+		var p4, comment interface{}
+		if instruction.P4.Valid {
+			p4 = instruction.P4.String
+		}
+		if instruction.Comment.Valid {
+			comment = instruction.Comment.String
+		}
+		rows = append(rows, []interface{}{
+			instruction.Address,
+			strings.Repeat("  ", instruction.Indent) + instruction.Opcode,
+			instruction.P1,
+			instruction.P2,
+			instruction.P3,
+			p4,
+			instruction.P5,
+			comment,
+		})
+	}
+
+	header := []string{"addr", "opcode", "p1", "p2", "p3", "p4", "p5", "comment"}
+	return db.PrintRows(header, rows, outF, false, printMode)
+}
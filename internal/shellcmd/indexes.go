@@ -2,6 +2,7 @@ package shellcmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
 	"github.com/spf13/cobra"
@@ -10,21 +11,26 @@ import (
 var indexesCmd = &cobra.Command{
 	Use:   ".indexes ?TABLE?",
 	Short: "List indexes in a table or database",
-	Long:  `List all indexes in a table or in the entire database if no table is specified.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `List all indexes in a table or in the entire database if no table is specified.
+Indexes of attached databases are listed as schema.index and TABLE can be given as schema.table.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
 		if !ok {
 			return fmt.Errorf("missing db connection")
 		}
-		var schemaStatement string
 
-		if len(args) == 1 {
-			schemaStatement = "SELECT name FROM sqlite_master WHERE type='index' AND tbl_name like '" + args[0] + "'"
-		} else {
-			schemaStatement = "SELECT name FROM sqlite_master WHERE type='index'"
+		pattern, err := parseSchemaPattern(config, args)
+		if err != nil {
+			return err
 		}
 
-		return config.Db.ExecuteAndPrintStatements(schemaStatement, config.OutF, true, enums.TABLE_MODE)
+		indexStatements := make([]string, 0, len(pattern.schemas))
+		for _, schema := range pattern.schemas {
+			indexStatements = append(indexStatements, fmt.Sprintf("SELECT %s FROM %s WHERE type='index'%s",
+				qualifiedName(schema, "name"), schemaTable(schema), pattern.nameFilter("tbl_name")))
+		}
+
+		return config.Db.ExecuteAndPrintStatements(strings.Join(indexStatements, " UNION ALL "), config.OutF, true, enums.TABLE_MODE)
 	},
 }
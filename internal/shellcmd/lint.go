@@ -0,0 +1,51 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   ".lint [STATEMENT]",
+	Short: "Report common schema issues and missing indexes",
+	Long: `Report tables without primary key, foreign keys without an index covering them, redundant indexes and foreign
+keys declared with a different type than the columns they reference. With STATEMENT, report the tables it reads entirely instead, according to EXPLAIN
+QUERY PLAN, with a suggested index.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		var issues []db.LintIssue
+		var err error
+		if len(args) == 0 {
+			issues, err = config.Db.LintSchema()
+		} else {
+			issues, err = config.Db.LintQuery(strings.TrimSuffix(strings.TrimSpace(strings.Join(args, " ")), ";"))
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(issues) == 0 {
+			fmt.Fprintln(config.OutF, "No issues found")
+			return nil
+		}
+
+		rows := make([][]interface{}, 0, len(issues))
+		for _, issue := range issues {
+			rows = append(rows, []interface{}{issue.Check, issue.Object, issue.Message, issue.Suggestion})
+		}
+		return db.PrintRows([]string{"check", "object", "message", "suggestion"}, rows, config.OutF, false, config.GetMode())
+	},
+}
+
+func init() {
+	// the statement can contain values like -1
+	lintCmd.Flags().SetInterspersed(false)
+}
@@ -0,0 +1,98 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   ".migrate ?status? DIR",
+	Short: "Apply the pending *.sql migrations of a directory",
+	Long: `Apply the *.sql files of a directory in order of name, recording the version and checksum of each one in the
+` + db.MigrationsTable + ` table. Migrations already applied are skipped, and nothing is applied if one of them was
+modified since. Each migration is applied in a transaction of its own, so the files can't contain BEGIN, COMMIT or
+ROLLBACK statements. Use --dry-run to print the statements of the pending migrations instead, and ".migrate status DIR" to
+list the state of every migration.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 2 {
+			if args[0] != "status" {
+				return fmt.Errorf("unknown migrate action %s. Use \".migrate DIR\" or \".migrate status DIR\"", args[0])
+			}
+			return PrintMigrationStatus(config, args[1])
+		}
+
+		return Migrate(config, args[0], dryRun)
+	},
+}
+
+// Migrate applies the pending migrations of dir, or prints their statements when dryRun is set
+func Migrate(config *DbCmdConfig, dir string, dryRun bool) error {
+	migrations, err := db.ReadMigrations(dir)
+	if err != nil {
+		return err
+	}
+	pending, err := config.Db.GetPendingMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Fprintln(config.OutF, "The database is up to date")
+		return nil
+	}
+
+	if dryRun {
+		for _, migration := range pending {
+			fmt.Fprintf(config.OutF, "-- %s\n", migration.Version)
+			for _, query := range config.Db.SplitMigration(migration) {
+				fmt.Fprintln(config.OutF, strings.TrimSuffix(strings.TrimSpace(query), ";")+";")
+			}
+		}
+		return nil
+	}
+
+	for _, migration := range pending {
+		if err := config.Db.ApplyMigration(migration); err != nil {
+			return err
+		}
+		fmt.Fprintf(config.OutF, "Applied %s\n", migration.Version)
+	}
+	return nil
+}
+
+// PrintMigrationStatus lists the migrations of dir and whether they were applied
+func PrintMigrationStatus(config *DbCmdConfig, dir string) error {
+	migrations, err := db.ReadMigrations(dir)
+	if err != nil {
+		return err
+	}
+	statuses, err := config.Db.GetMigrationStatus(migrations)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		rows = append(rows, []interface{}{status.Version, string(status.State), status.AppliedAt})
+	}
+	return db.PrintRows([]string{"version", "state", "applied_at"}, rows, config.OutF, false, config.GetMode())
+}
+
+func init() {
+	migrateCmd.Flags().Bool("dry-run", false, "Print the statements of the pending migrations without applying them")
+}
@@ -0,0 +1,52 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var openCmd = &cobra.Command{
+	Use:   ".open URL_OR_PATH",
+	Short: "Close the current database and open another one",
+	Long:  `Close the database of the active connection and open a local file or a remote database in its place. The current database is kept if the new one can't be opened.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		authToken, err := cmd.Flags().GetString("auth")
+		if err != nil {
+			return err
+		}
+
+		newDb, err := openAndTestDb(config.Db, args[0], authToken)
+		if err != nil {
+			return err
+		}
+
+		config.SetDb(newDb)
+		return nil
+	},
+}
+
+// openAndTestDb opens a database with the connection options of the current one
+func openAndTestDb(current *db.Db, dbUri string, authToken string) (*db.Db, error) {
+	newDb, err := current.OpenWithSameOptions(dbUri, authToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := newDb.TestConnection(); err != nil {
+		newDb.Close()
+		return nil, err
+	}
+	return newDb, nil
+}
+
+func init() {
+	openCmd.Flags().String("auth", "", "Auth token of the database")
+}
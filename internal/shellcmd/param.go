@@ -0,0 +1,85 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var paramCmd = &cobra.Command{
+	Use:   ".param set|unset|list|clear ?NAME? ?VALUE?",
+	Short: "Manage the values bound to statement parameters",
+	Long: `Manage the values bound to the ?NNN, :name, @name and $name parameters of the executed statements:
+
+  .param set NAME VALUE  Bind VALUE to the parameter NAME, e.g. ".param set :id 42" or ".param set 1 'text'"
+  .param unset NAME      Remove the value of the parameter NAME
+  .param list            List the parameters and their values
+  .param clear           Remove the values of every parameter
+
+Values are read as SQL literals: integers, reals, NULL and quoted strings. Other values are taken as text. Parameters
+without a value are bound to NULL.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+		parameters := config.GetParameters()
+
+		switch action := args[0]; action {
+		case "set":
+			if len(args) < 3 {
+				return fmt.Errorf("usage: .param set NAME VALUE")
+			}
+			name, err := db.NormalizeParameterName(args[1])
+			if err != nil {
+				return err
+			}
+			// the value is taken from the line, since splitting it in arguments loses the spaces inside quoted strings
+			parameters[name] = db.ParseParameterValue(remainderAfterFields(config.GetCommandLine(), 3))
+		case "unset":
+			if len(args) != 2 {
+				return fmt.Errorf("usage: .param unset NAME")
+			}
+			name, err := db.NormalizeParameterName(args[1])
+			if err != nil {
+				return err
+			}
+			delete(parameters, name)
+		case "list":
+			rows := make([][]interface{}, 0, len(parameters))
+			for _, name := range parameters.SortedNames() {
+				rows = append(rows, []interface{}{name, parameters[name]})
+			}
+			return db.PrintRows([]string{"name", "value"}, rows, config.OutF, false, config.GetMode())
+		case "clear":
+			for name := range parameters {
+				delete(parameters, name)
+			}
+		default:
+			return fmt.Errorf("unknown param action %s. Valid actions are set, unset, list, clear", action)
+		}
+		return nil
+	},
+}
+
+// remainderAfterFields returns the text of line after its first n fields, keeping the spaces inside it
+func remainderAfterFields(line string, n int) string {
+	rest := strings.TrimSpace(line)
+	for i := 0; i < n; i++ {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+	}
+	return rest
+}
+
+func init() {
+	paramCmd.Flags().SetInterspersed(false)
+}
@@ -0,0 +1,41 @@
+package shellcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   ".prompt ?TEMPLATE?",
+	Short: "Change the prompt of new statements",
+	Long: `Change the prompt of new statements, or restore the default one when no template is given. The template may be
+quoted to keep a trailing space, and may contain these placeholders:
+
+  {db}    The host of the database, or its file name if local
+  {conn}  The name of the active connection
+  {mode}  The output mode
+  {tx}    "*" while a transaction is open
+
+e.g. .prompt "{db}{tx} ({mode}) > "`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		// the template is taken from the line, since splitting it in arguments loses its spaces
+		template := remainderAfterFields(config.GetCommandLine(), len(strings.Fields(config.GetCommandLine()))-len(args))
+		if len(template) >= 2 && (template[0] == '"' || template[0] == '\'') && template[len(template)-1] == template[0] {
+			template = template[1 : len(template)-1]
+		}
+
+		config.SetPromptTemplate(template)
+		return nil
+	},
+}
+
+func init() {
+	promptCmd.Flags().SetInterspersed(false)
+}
@@ -2,40 +2,22 @@ package shellcmd
 
 import (
 	"fmt"
-	"os"
-	"strings"
 
-	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
 	"github.com/spf13/cobra"
 )
 
 var readCmd = &cobra.Command{
 	Use:   ".read FILENAME",
 	Short: "Execute commands from a file",
-	Args:  cobra.ExactArgs(1),
+	Long: `Execute the statements and dot commands of a file as if they were typed in the shell, in the current mode.
+Relative paths of .read commands inside the file are resolved from its directory. Use "-" to read the standard input.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
 		if !ok {
 			return fmt.Errorf("missing db connection")
 		}
 
-		file, err := os.Open(args[0])
-		if err != nil {
-			return err
-		}
-		defer file.Close()
-
-		stat, err := file.Stat()
-		if err != nil {
-			return err
-		}
-
-		content := make([]byte, stat.Size())
-		_, err = file.Read(content)
-		if err != nil {
-			return err
-		}
-
-		return config.Db.ExecuteAndPrintStatements(strings.TrimSpace(string(content)), config.OutF, false, enums.TABLE_MODE)
+		return config.ExecuteScript(args[0])
 	},
 }
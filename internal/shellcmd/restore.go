@@ -0,0 +1,296 @@
+package shellcmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/spf13/cobra"
+	"github.com/tursodatabase/libsql-client-go/sqliteparser"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+const defaultRestoreBatchSize = 200
+
+const maxReportedStatementLength = 80
+
+var restoreCmd = &cobra.Command{
+	Use:   ".restore FILE|DIR",
+	Short: "Load a dump into the database in batches",
+	Long: `Load a dump produced by .dump into the database. The file is read incrementally and its statements are sent in
+transactions of --batch-size statements. DIR must contain the manifest written by ".dump --dir" and gzip files are detected
+automatically. When a batch fails it is rolled back and the restore can be resumed with --resume-from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		restorer, err := newRestorer(cmd, config, args[0])
+		if err != nil {
+			return err
+		}
+
+		files, err := getRestoreFiles(args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if err := restorer.restoreFile(file); err != nil {
+				return err
+			}
+		}
+
+		restorer.finish()
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().Int("batch-size", defaultRestoreBatchSize, "Number of statements sent in each transaction")
+	restoreCmd.Flags().Int("resume-from", 1, "Skip every statement before the given statement number")
+	restoreCmd.Flags().Bool("progress", false, "Report restore progress on stderr")
+}
+
+type restoreStatement struct {
+	db.ScannedStatement
+	number int
+	file   string
+}
+
+type restorer struct {
+	config     *DbCmdConfig
+	source     string
+	batchSize  int
+	resumeFrom int
+	progress   bool
+
+	batch           []restoreStatement
+	statementNumber int
+	restored        int
+}
+
+func newRestorer(cmd *cobra.Command, config *DbCmdConfig, source string) (*restorer, error) {
+	r := &restorer{config: config, source: source}
+
+	var err error
+	if r.batchSize, err = cmd.Flags().GetInt("batch-size"); err != nil {
+		return nil, err
+	}
+	if r.batchSize < 1 {
+		return nil, fmt.Errorf("batch-size must be at least 1")
+	}
+	if r.resumeFrom, err = cmd.Flags().GetInt("resume-from"); err != nil {
+		return nil, err
+	}
+	if r.resumeFrom < 1 {
+		return nil, fmt.Errorf("resume-from must be at least 1")
+	}
+	if r.progress, err = cmd.Flags().GetBool("progress"); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// getRestoreFiles returns the files of a dump in the order they must be restored
+func getRestoreFiles(source string) ([]string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{source}, nil
+	}
+
+	manifestContent, err := os.ReadFile(filepath.Join(source, dumpManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest dumpManifest
+	if err := json.Unmarshal(manifestContent, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid dump manifest: %w", err)
+	}
+
+	files := make([]string, 0, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		files = append(files, filepath.Join(source, table.File))
+	}
+	return files, nil
+}
+
+type restoreInput struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (i *restoreInput) Close() {
+	for _, closer := range i.closers {
+		closer.Close()
+	}
+}
+
+func openRestoreInput(filePath string) (*restoreInput, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	input := &restoreInput{closers: []io.Closer{file}}
+
+	reader := bufio.NewReader(file)
+	input.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		input.Reader = gzipReader
+		input.closers = append([]io.Closer{gzipReader}, input.closers...)
+	}
+
+	return input, nil
+}
+
+func (r *restorer) restoreFile(filePath string) error {
+	input, err := openRestoreInput(filePath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	scanner := db.NewStatementScanner(input)
+	for scanner.Scan() {
+		r.statementNumber++
+
+		statement := restoreStatement{ScannedStatement: scanner.Statement(), number: r.statementNumber, file: filePath}
+		firstTokenType := getFirstTokenType(statement.Text)
+		// pragmas set up the connection, like foreign_keys=OFF, so they are needed even when resuming after them
+		if r.statementNumber < r.resumeFrom && firstTokenType != sqliteparser.SQLiteLexerPRAGMA_ {
+			continue
+		}
+
+		switch firstTokenType {
+		case sqliteparser.SQLiteLexerBEGIN_, sqliteparser.SQLiteLexerCOMMIT_, sqliteparser.SQLiteLexerEND_:
+			// the restore manages its own transactions
+			continue
+		case sqliteparser.SQLiteLexerPRAGMA_:
+			// pragmas like foreign_keys are no-ops inside a transaction
+			if err := r.flushBatch(); err != nil {
+				return err
+			}
+			r.batch = append(r.batch, statement)
+			if err := r.executeBatch(false); err != nil {
+				return err
+			}
+		default:
+			r.batch = append(r.batch, statement)
+			if len(r.batch) >= r.batchSize {
+				if err := r.flushBatch(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	// batches don't span files, so their errors name the right one
+	return r.flushBatch()
+}
+
+func (r *restorer) flushBatch() error {
+	return r.executeBatch(true)
+}
+
+func (r *restorer) executeBatch(inTransaction bool) error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+
+	texts := make([]string, 0, len(r.batch)+2)
+	if inTransaction {
+		texts = append(texts, "BEGIN")
+	}
+	for _, statement := range r.batch {
+		texts = append(texts, statement.Text)
+	}
+	if inTransaction {
+		texts = append(texts, "COMMIT")
+	}
+
+	succeeded, err := r.config.Db.ExecuteStatementsDiscardingRows(strings.Join(texts, ";\n") + ";")
+	if err != nil {
+		if inTransaction {
+			_, _ = r.config.Db.ExecuteStatementsDiscardingRows("ROLLBACK;")
+			// the result of BEGIN is also counted
+			succeeded--
+		}
+		return r.newBatchError(succeeded, err)
+	}
+
+	r.restored += len(r.batch)
+	last := r.batch[len(r.batch)-1]
+	r.batch = r.batch[:0]
+	if r.progress {
+		fmt.Fprintf(r.config.ErrF, "\rrestored %d statements (line %d of %s)", r.restored, last.Line, last.file)
+	}
+
+	return nil
+}
+
+func (r *restorer) finish() {
+	if r.progress {
+		fmt.Fprintln(r.config.ErrF)
+	}
+}
+
+func (r *restorer) newBatchError(succeeded int, err error) error {
+	first := r.batch[0]
+	last := r.batch[len(r.batch)-1]
+
+	var description string
+	if succeeded >= 0 && succeeded < len(r.batch) {
+		failed := r.batch[succeeded]
+		description = fmt.Sprintf("failed to restore statement #%d at line %d of %s (%s): %v",
+			failed.number, failed.Line, failed.file, shortenStatement(failed.Text), err)
+	} else {
+		description = fmt.Sprintf("failed to restore statements #%d to #%d at lines %d to %d of %s: %v",
+			first.number, last.number, first.Line, last.Line, last.file, err)
+	}
+
+	return fmt.Errorf("%s\nstatements #%d to #%d were not applied. Run \".restore %s --resume-from %d\" to resume",
+		description, first.number, last.number, r.source, first.number)
+}
+
+func shortenStatement(statement string) string {
+	statement = strings.Join(strings.Fields(statement), " ")
+	if len(statement) > maxReportedStatementLength {
+		return statement[:maxReportedStatementLength-3] + "..."
+	}
+	return statement
+}
+
+func getFirstTokenType(statement string) int {
+	lexer := sqliteparser.NewSQLiteLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+	for {
+		token := lexer.NextToken()
+		if token.GetTokenType() == antlr.TokenEOF {
+			return antlr.TokenEOF
+		}
+		if token.GetChannel() == antlr.TokenDefaultChannel {
+			return token.GetTokenType()
+		}
+	}
+}
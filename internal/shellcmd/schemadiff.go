@@ -0,0 +1,68 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaDiffCmd = &cobra.Command{
+	Use:   ".schemadiff OTHER_URL_OR_PATH",
+	Short: "Print the SQL that migrates the schema into another one",
+	Long: `Compare the schema of the database with the schema of another one and print the statements that make the database
+match it, or the other way around with --reverse. Tables only gaining columns are changed with ALTER TABLE ADD COLUMN,
+other table changes use the rebuild pattern of SQLite: create the new table, copy the records, drop the old table and
+rename the new one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		authToken, err := cmd.Flags().GetString("auth")
+		if err != nil {
+			return err
+		}
+		reverse, err := cmd.Flags().GetBool("reverse")
+		if err != nil {
+			return err
+		}
+
+		return PrintSchemaDiff(config, args[0], authToken, reverse)
+	},
+}
+
+// PrintSchemaDiff prints the statements that migrate the schema of the database into the schema of the other one, or the
+// other way around when reverse is set
+func PrintSchemaDiff(config *DbCmdConfig, otherUri string, authToken string, reverse bool) error {
+	other, err := openAndTestDb(config.Db, otherUri, authToken)
+	if err != nil {
+		return err
+	}
+	defer other.Close()
+
+	from, to := config.Db, other
+	if reverse {
+		from, to = other, config.Db
+	}
+
+	statements, err := from.DiffSchema(to)
+	if err != nil {
+		return err
+	}
+
+	if len(statements) == 0 {
+		fmt.Fprintln(config.OutF, "-- the schemas are identical")
+		return nil
+	}
+	for _, statement := range statements {
+		fmt.Fprintln(config.OutF, statement)
+	}
+	return nil
+}
+
+func init() {
+	schemaDiffCmd.Flags().String("auth", "", "Auth token of the other database")
+	schemaDiffCmd.Flags().Bool("reverse", false, "Print the SQL that migrates the other database into this one instead")
+}
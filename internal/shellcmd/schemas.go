@@ -2,6 +2,7 @@ package shellcmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
 	"github.com/spf13/cobra"
@@ -10,6 +11,7 @@ import (
 var schemaCmd = &cobra.Command{
 	Use:   ".schema ?PATTERN?",
 	Short: `Show table schemas.`,
+	Long:  `Show table schemas. Use schema.pattern to only show the schemas of an attached database.`,
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
@@ -17,17 +19,20 @@ var schemaCmd = &cobra.Command{
 			return fmt.Errorf("missing db connection")
 		}
 
-		schemaStatement := `select sql || ';' from sqlite_schema
-			where name not like 'sqlite_%'
+		pattern, err := parseSchemaPattern(config, args)
+		if err != nil {
+			return err
+		}
+
+		schemaStatements := make([]string, 0, len(pattern.schemas))
+		for i, schema := range pattern.schemas {
+			schemaStatements = append(schemaStatements, fmt.Sprintf(`select sql || ';' as sql, %d as schema_order, tbl_name from %s
+			where name not like 'sqlite_%%'
 			and name != '_litestream_seq'
 			and name != '_litestream_lock'
-			and name != 'libsql_wasm_func_table'`
-
-		if len(args) == 1 {
-			schemaStatement += " and name like '" + args[0] + "'"
+			and name != 'libsql_wasm_func_table'%s`, i, schemaTable(schema), pattern.nameFilter("name")))
 		}
-
-		schemaStatement += " order by tbl_name"
+		schemaStatement := "select sql from (" + strings.Join(schemaStatements, " union all ") + ") order by schema_order, tbl_name"
 
 		return config.Db.ExecuteAndPrintStatements(schemaStatement, config.OutF, true, enums.TABLE_MODE)
 	},
@@ -0,0 +1,47 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   ".stats",
+	Short: "Report the size of the database, its tables and indexes",
+	Long: `Report the page size, page count and free pages of the database, followed by the rows and bytes of each table and
+index, biggest first. Sizes come from the dbstat virtual table when SQLite provides it. Otherwise they are estimated from
+the length of the stored values and flagged as such.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		stats, err := config.Db.GetStats()
+		if err != nil {
+			return err
+		}
+
+		databaseHeader := []string{"page_size", "page_count", "freelist_count", "size", "freelist_size"}
+		databaseRows := [][]interface{}{{stats.PageSize, stats.PageCount, stats.FreelistCount, stats.Size(), stats.FreelistSize()}}
+		if err := db.PrintRows(databaseHeader, databaseRows, config.OutF, false, config.GetMode()); err != nil {
+			return err
+		}
+
+		objectRows := make([][]interface{}, 0, len(stats.Objects))
+		for _, object := range stats.Objects {
+			var pages interface{}
+			if object.Pages.Valid {
+				pages = object.Pages.Int64
+			}
+			objectRows = append(objectRows, []interface{}{object.Name, object.Type, object.TableName, object.Rows, object.Bytes, pages, object.Estimated})
+		}
+
+		objectHeader := []string{"name", "type", "table", "rows", "bytes", "pages", "estimated"}
+		return db.PrintRows(objectHeader, objectRows, config.OutF, false, config.GetMode())
+	},
+}
@@ -2,28 +2,38 @@ package shellcmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/libsql/libsql-shell-go/pkg/shell/enums"
 	"github.com/spf13/cobra"
 )
 
 var tableCmd = &cobra.Command{
-	Use:   ".tables",
+	Use:   ".tables ?PATTERN?",
 	Short: `List all existing tables in the database.`,
-	Args:  cobra.NoArgs,
+	Long:  `List all existing tables in the database. Tables of attached databases are listed as schema.table and PATTERN can be restricted to one database with the same notation.`,
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
 		if !ok {
 			return fmt.Errorf("missing db connection")
 		}
 
-		tableStatement := `select name from sqlite_schema
+		pattern, err := parseSchemaPattern(config, args)
+		if err != nil {
+			return err
+		}
+
+		tableStatements := make([]string, 0, len(pattern.schemas))
+		for _, schema := range pattern.schemas {
+			tableStatements = append(tableStatements, fmt.Sprintf(`select %s as name from %s
 			where type = 'table'
-			and name not like 'sqlite_%'
+			and name not like 'sqlite_%%'
 			and name != '_litestream_seq'
 			and name != '_litestream_lock'
-			and name != 'libsql_wasm_func_table'
-			order by name`
+			and name != 'libsql_wasm_func_table'%s`, qualifiedName(schema, "name"), schemaTable(schema), pattern.nameFilter("name")))
+		}
+		tableStatement := strings.Join(tableStatements, " union all ") + " order by name"
 
 		return config.Db.ExecuteAndPrintStatements(tableStatement, config.OutF, true, enums.TABLE_MODE)
 	},
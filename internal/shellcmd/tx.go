@@ -0,0 +1,39 @@
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/libsql/libsql-shell-go/internal/db"
+)
+
+var txCmd = &cobra.Command{
+	Use:   ".tx",
+	Short: "Show the open transaction and its savepoints",
+	Long: `Show the transaction opened by BEGIN or SAVEPOINT on the current connection, followed by its savepoints from the
+outermost to the innermost.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, ok := cmd.Context().Value(dbCtx{}).(*DbCmdConfig)
+		if !ok {
+			return fmt.Errorf("missing db connection")
+		}
+
+		stack := config.Db.TransactionStack()
+		if len(stack) == 0 {
+			fmt.Fprintln(config.OutF, "No transaction is open")
+			return nil
+		}
+
+		rows := make([][]interface{}, 0, len(stack))
+		for i, level := range stack {
+			levelType := "savepoint"
+			if level.Savepoint == "" {
+				levelType = "transaction"
+			}
+			rows = append(rows, []interface{}{i + 1, levelType, level.Savepoint})
+		}
+		return db.PrintRows([]string{"level", "type", "savepoint"}, rows, config.OutF, false, config.GetMode())
+	},
+}
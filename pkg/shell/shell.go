@@ -26,6 +26,10 @@ type ShellConfig struct {
 	AfterDbConnectionCallback func()
 	DisableAutoCompletion     bool
 	SchemaDb                  bool
+	// Echo prints each statement before its results
+	Echo bool
+	// PromptTemplate is the prompt of new statements. It may contain the placeholders {db}, {conn}, {mode} and {tx}
+	PromptTemplate string
 }
 
 func RunShell(config ShellConfig) error {
@@ -49,6 +53,7 @@ func RunShell(config ShellConfig) error {
 	if err != nil {
 		return err
 	}
+	defer shellInstance.Close()
 
 	go func() {
 		for range signals {
@@ -76,6 +81,7 @@ func RunShellLine(config ShellConfig, line string) error {
 	if err != nil {
 		return err
 	}
+	defer shellInstance.Close()
 
 	go func() {
 		<-signals
@@ -95,5 +101,7 @@ func publicToInternalConfig(publicConfig ShellConfig) shell.ShellConfig {
 		QuietMode:             publicConfig.QuietMode,
 		WelcomeMessage:        publicConfig.WelcomeMessage,
 		DisableAutoCompletion: publicConfig.DisableAutoCompletion,
+		Echo:                  publicConfig.Echo,
+		PromptTemplate:        publicConfig.PromptTemplate,
 	}
 }
@@ -1,12 +1,16 @@
 package main_test
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/libsql/libsql-shell-go/internal/cmd"
 	"github.com/libsql/libsql-shell-go/test/utils"
 )
 
@@ -88,14 +92,35 @@ func (s *DBRootCommandShellSuite) Test_WhenCallDotHelpCommand_ExpectAListWithAll
 	s.tc.Assert(errS, qt.Equals, "")
 
 	expectedHelp :=
-		`.dump       Render database content as SQL
-  .help       List of all available commands.
-  .indexes    List indexes in a table or database
-  .mode       Set output mode
-  .quit       Exit this program
-  .read       Execute commands from a file
-  .schema     Show table schemas.
-  .tables     List all existing tables in the database.`
+		`.backup      Copy the database into a new local SQLite file
+  .bail        Stop at the first failing statement
+  .clone       Copy the schema and records into another database
+  .connect     Open a named connection or switch to it
+  .connections List the opened connections
+  .databases   List the main and attached databases
+  .describe    Describe the columns of a table
+  .dump        Render database content as SQL
+  .echo        Print each statement before its results
+  .edit        Write the current or last statement in $EDITOR and execute it
+  .eqp         Show the query plan before the results of each statement
+  .erd         Export an entity relationship diagram of the schema
+  .explain     Show the query plan of a statement
+  .help        List of all available commands.
+  .indexes     List indexes in a table or database
+  .lint        Report common schema issues and missing indexes
+  .migrate     Apply the pending *.sql migrations of a directory
+  .mode        Set output mode
+  .open        Close the current database and open another one
+  .param       Manage the values bound to statement parameters
+  .prompt      Change the prompt of new statements
+  .quit        Exit this program
+  .read        Execute commands from a file
+  .restore     Load a dump into the database in batches
+  .schema      Show table schemas.
+  .schemadiff  Print the SQL that migrates the schema into another one
+  .stats       Report the size of the database, its tables and indexes
+  .tables      List all existing tables in the database.
+  .tx          Show the open transaction and its savepoints`
 	s.tc.Assert(outS, qt.Equals, expectedHelp)
 }
 
@@ -122,6 +147,83 @@ func (s *DBRootCommandShellSuite) Test_GivenAEmptyDb_WhenCallDotReadCommandPassi
 	s.tc.Assert(outS, qt.Equals, "")
 }
 
+func (s *DBRootCommandShellSuite) Test_GivenAScriptWithDotCommandsAndANestedRead_WhenCallDotReadCommand_ExpectThemExecutedLikeTypedLines() {
+	dir := s.tc.C.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "scripts"), 0755)
+	s.tc.Assert(err, qt.IsNil)
+	err = os.WriteFile(filepath.Join(dir, "scripts", "main.sql"), []byte(`CREATE TABLE testread (name TEXT);
+.read data.sql
+.mode csv
+SELECT *
+  FROM testread;
+`), 0644)
+	s.tc.Assert(err, qt.IsNil)
+	err = os.WriteFile(filepath.Join(dir, "scripts", "data.sql"), []byte("INSERT INTO testread VALUES ('nested')"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".read " + filepath.Join(dir, "scripts", "main.sql"), "SELECT count(*) AS count FROM testread;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "name\nnested\ncount\n1")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenBailOff_WhenCallDotReadCommandWithFailingLines_ExpectEachErrorReportedWithTheRestExecuted() {
+	_, filePath := s.tc.CreateTempFile(".mode csv\nSELECT * FROM missing_table;\n.unknown\nSELECT 1 AS a;\n")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".bail off", ".read " + filePath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Matches, `Error: statement 2 at line 2 of .*test.txt failed: .*missing_table.*
+  SELECT \* FROM missing_table
+Error: statement 3 at line 3 of .*test.txt failed: unknown command or invalid arguments: ".unknown". Enter ".help" for help
+  .unknown
+Error: 2 of 4 statements failed`)
+	s.tc.Assert(outS, qt.Equals, "a\n1")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAScriptWithAFailingStatement_WhenCallDotReadCommand_ExpectTheLineOfTheStatementReported() {
+	_, filePath := s.tc.CreateTempFile("CREATE TABLE testread (name TEXT);\n\nINSERT INTO testread\n  VALUES ('a'); INSERT INTO\n  missing_table VALUES ('b');\nSELECT * FROM testread;\n")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".read " + filePath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Matches, `Error: statement 3 at line 4 of .*test.txt failed: .*missing_table.*
+  INSERT INTO
+  missing_table VALUES \('b'\)`)
+	s.tc.Assert(outS, qt.Equals, "")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAStringLiteralSpanningIndentedLines_WhenTypedOrRead_ExpectItsSpacesKept() {
+	_, filePath := s.tc.CreateTempFile("INSERT INTO testread VALUES ('read\n    indented  \n');\n")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{
+		"CREATE TABLE testread (name TEXT);",
+		"INSERT INTO testread VALUES ('typed",
+		"    indented  ",
+		"');",
+		".read " + filePath,
+		".mode csv",
+		"SELECT length(name) AS length FROM testread;",
+	})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "length\n21\n20")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenALineEndingWithASemicolonInsideAStringLiteral_WhenTypedOrRead_ExpectStatementContinued() {
+	_, filePath := s.tc.CreateTempFile("INSERT INTO testread VALUES ('read;\ndef');\n")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{
+		"CREATE TABLE testread (name TEXT);",
+		"INSERT INTO testread VALUES ('typed;",
+		"def');",
+		".read " + filePath,
+		".mode csv",
+		"SELECT name FROM testread;",
+	})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "name\n\"typed;\ndef\"\n\"read;\ndef\"")
+}
+
 func (s *DBRootCommandShellSuite) Test_GivenADBWithTwoTables_WhenCreateTwoIndexesAndCallDotIndexesCommand_ExpectToReturnTheIndexes() {
 	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}})
 	s.tc.CreateSimpleTable("simple_table_2", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}})
@@ -300,6 +402,282 @@ func (s *DBRootCommandShellSuite) Test_GivenATableNameWithSpecialCharacters_When
 	s.tc.AssertSqlEquals(outS, prefix+expected)
 }
 
+func (s *DBRootCommandShellSuite) Test_GivenATableWithRecords_WhenCallDotDumpCommandWithStatementsAndProgress_ExpectStatementDumpAndProgressReport() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}})
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".dump --statements --progress"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "dumped table simple_table (2 rows)")
+
+	expected := "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE simple_table (id INTEGER PRIMARY KEY, textField TEXT, intField INTEGER);\nINSERT INTO simple_table VALUES(1,'value1',1);\nINSERT INTO simple_table VALUES(2,'value2',2);\nCOMMIT;"
+	s.tc.AssertSqlEquals(outS, expected)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithRecords_WhenCallDotDumpCommandTwice_ExpectFlagsNotToLeakBetweenCalls() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}})
+
+	_, errS, err := s.tc.ExecuteShell([]string{".dump --statements --progress", ".dump --statements"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "dumped table simple_table (1 rows)")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithRecords_WhenCallDotDumpCommandWithRowsPerInsert_ExpectMultiRowInserts() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}, {TextField: "value3", IntField: 3}})
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".dump --rows-per-insert 2"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	expected := "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE simple_table (id INTEGER PRIMARY KEY, textField TEXT, intField INTEGER);\nINSERT INTO simple_table VALUES(1,'value1',1),(2,'value2',2);\nINSERT INTO simple_table VALUES(3,'value3',3);\nCOMMIT;"
+	s.tc.AssertSqlEquals(outS, expected)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithRecords_WhenCallDotDumpCommandWithGzipOutput_ExpectCompressedFile() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}})
+	dumpPath := filepath.Join(s.tc.C.TempDir(), "dump.sql.gz")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".dump --statements --gzip --output " + dumpPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "")
+
+	s.tc.AssertSqlEquals(utils.ReadGzipFile(s.T(), dumpPath), "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE simple_table (id INTEGER PRIMARY KEY, textField TEXT, intField INTEGER);\nINSERT INTO simple_table VALUES(1,'value1',1);\nCOMMIT;\n")
+}
+
+func (s *DBRootCommandShellSuite) Test_WhenCallDotDumpWithGzipAndNoOutput_ExpectError() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".dump --gzip"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Error: --gzip needs --output or --dir")
+	s.tc.Assert(outS, qt.Equals, "")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenTwoTables_WhenCallDotDumpCommandWithDir_ExpectOneFilePerTableAndManifest() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}})
+	s.tc.CreateEmptySimpleTable("another_simple_table")
+	dumpDir := filepath.Join(s.tc.C.TempDir(), "dump")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".dump --dir " + dumpDir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "")
+
+	manifestContent, err := os.ReadFile(filepath.Join(dumpDir, "manifest.json"))
+	s.tc.Assert(err, qt.IsNil)
+	var manifest struct {
+		Tables []struct {
+			Name string `json:"name"`
+			File string `json:"file"`
+			Rows int    `json:"rows"`
+		} `json:"tables"`
+	}
+	s.tc.Assert(json.Unmarshal(manifestContent, &manifest), qt.IsNil)
+	s.tc.Assert(manifest.Tables, qt.HasLen, 2)
+
+	for _, table := range manifest.Tables {
+		content, err := os.ReadFile(filepath.Join(dumpDir, table.File))
+		s.tc.Assert(err, qt.IsNil)
+		s.tc.Assert(strings.Contains(string(content), "CREATE TABLE "+table.Name+" "), qt.IsTrue)
+		if table.Name == "simple_table" {
+			s.tc.Assert(table.Rows, qt.Equals, 1)
+			s.tc.Assert(strings.Contains(string(content), "INSERT INTO simple_table VALUES(1,'value1',1);"), qt.IsTrue)
+		}
+	}
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADumpFile_WhenCallDotRestoreCommand_ExpectTablesAndRecordsRestored() {
+	content := `PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE simple_table (id INTEGER PRIMARY KEY, textField TEXT, intField INTEGER);
+INSERT INTO simple_table VALUES(1,'value1',1),(2,'value2',2);
+INSERT INTO simple_table VALUES(3,'value;3',3);
+CREATE INDEX idx_intfield on simple_table (intField);
+COMMIT;`
+	file, filePath := s.tc.CreateTempFile(content)
+	defer file.Close()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".restore --batch-size 2 " + filePath, "SELECT * FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id", "textField", "intField"}, [][]string{{"1", "value1", "1"}, {"2", "value2", "2"}, {"3", "value;3", "3"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADumpFileWithAnInvalidStatement_WhenCallDotRestoreCommand_ExpectFailingStatementReportedAndResumable() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+	content := `INSERT INTO simple_table VALUES(1,'value1',1);
+INSERT INTO simple_table VALUES(2,'value2',2);
+INSERT INTO non_existing_table VALUES(3,'value3',3);
+INSERT INTO simple_table VALUES(4,'value4',4);`
+	file, filePath := s.tc.CreateTempFile(content)
+	defer file.Close()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".restore --batch-size 2 " + filePath, "SELECT id FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id"}, [][]string{{"1"}, {"2"}}))
+	if strings.HasSuffix(s.dbUri, "test.sqlite") {
+		s.tc.Assert(errS, qt.Matches, `(?s)Error: failed to restore statement #3 at line 3 of .*: no such table: non_existing_table.*`)
+	}
+	s.tc.Assert(errS, qt.Contains, "--resume-from 3")
+
+	_, _, err = s.tc.Execute("CREATE TABLE non_existing_table (id INTEGER PRIMARY KEY, textField TEXT, intField INTEGER)")
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".restore --resume-from 3 " + filePath, "SELECT id FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id"}, [][]string{{"1"}, {"2"}, {"4"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADumpStartingWithPragmas_WhenResumeTheRestore_ExpectPragmasStillExecuted() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+	content := `PRAGMA user_version=7;
+INSERT INTO simple_table VALUES(1,'value1',1);
+INSERT INTO simple_table VALUES(2,'value2',2);`
+	file, filePath := s.tc.CreateTempFile(content)
+	defer file.Close()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".restore --resume-from 3 " + filePath, ".mode csv", "SELECT id FROM simple_table;", "PRAGMA user_version;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "id\n2\nuser_version\n7")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADirectoryDump_WhenCallDotRestoreCommand_ExpectTablesRestored() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}})
+	dumpDir := filepath.Join(s.tc.C.TempDir(), "dump")
+
+	_, errS, err := s.tc.ExecuteShell([]string{".dump --gzip --dir " + dumpDir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.DropAllTables()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".restore " + dumpDir, "SELECT * FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id", "textField", "intField"}, [][]string{{"1", "value1", "1"}, {"2", "value2", "2"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenTablesWithRecordsAndIndexes_WhenCallDotBackupCommand_ExpectLocalCopyWithSameContent() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}, {TextField: "value2", IntField: 2}})
+	s.tc.CreateAllTypesTable("alltypes", []utils.AllTypesTableEntry{
+		{TextNotNullable: "text", IntNotNullable: 1, FloatNotNullable: 1.5, UnknownNotNullable: 0.0, BlobNotNullable: "0123456789ABCDEF"},
+	})
+	_, _, err := s.tc.Execute("CREATE INDEX idx_intfield on simple_table (intField)")
+	s.tc.Assert(err, qt.IsNil)
+	backupPath := filepath.Join(s.tc.C.TempDir(), "backup.sqlite")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".backup " + backupPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "Backup of 2 tables and 3 rows written to "+backupPath)
+
+	originalDump, _, err := s.tc.ExecuteShell([]string{".dump --statements"})
+	s.tc.Assert(err, qt.IsNil)
+
+	backupTc := utils.NewTestContext(s.T(), backupPath, "")
+	defer backupTc.Close()
+	backupDump, errS, err := backupTc.ExecuteShell([]string{".dump"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(backupDump, qt.Equals, originalDump)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAFullTextSearchTable_WhenCallDotBackupCommand_ExpectItsRowsCopiedWithoutTheShadowTables() {
+	_, _, err := s.tc.Execute("CREATE VIRTUAL TABLE documents USING fts4(body); INSERT INTO documents VALUES ('hello world'), ('other');")
+	s.tc.Assert(err, qt.IsNil)
+	defer s.tc.Execute("DROP TABLE documents")
+	backupPath := filepath.Join(s.tc.C.TempDir(), "backup.sqlite")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".backup " + backupPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "Backup of 1 tables and 2 rows written to "+backupPath)
+
+	backupTc := utils.NewTestContext(s.T(), backupPath, "")
+	defer backupTc.Close()
+	outS, errS, err = backupTc.ExecuteShell([]string{".mode csv", "SELECT body FROM documents WHERE documents MATCH 'hello';"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "body\nhello world")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnExistingFile_WhenCallDotBackupCommand_ExpectError() {
+	file, filePath := s.tc.CreateTempFile("")
+	defer file.Close()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".backup " + filePath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Error: "+filePath+" already exists. The backup must be written to a new file")
+	s.tc.Assert(outS, qt.Equals, "")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenTablesWithForeignKeys_WhenCallDotCloneCommand_ExpectDestinationWithSameContent() {
+	_, _, err := s.tc.Execute(`CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent (id));
+		CREATE TABLE parent (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO parent VALUES (1, 'one'), (2, 'two'), (3, 'three');
+		INSERT INTO child VALUES (1, 1), (2, 1), (3, 3);
+		CREATE VIEW parent_names AS SELECT name FROM parent;`)
+	s.tc.Assert(err, qt.IsNil)
+	destinationPath := filepath.Join(s.tc.C.TempDir(), "clone.sqlite")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".clone --batch-size 2 " + destinationPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "Cloned 2 tables and 6 rows into "+destinationPath)
+
+	destinationTc := utils.NewTestContext(s.T(), destinationPath, "")
+	defer destinationTc.Close()
+	outS, errS, err = destinationTc.ExecuteShell([]string{"SELECT * FROM parent_names;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"name"}, [][]string{{"one"}, {"two"}, {"three"}}))
+
+	outS, errS, err = destinationTc.ExecuteShell([]string{"SELECT * FROM child;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id", "parent_id"}, [][]string{{"1", "1"}, {"2", "1"}, {"3", "3"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADestinationWithTheSameTable_WhenCallDotCloneCommand_ExpectErrorUnlessTruncating() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value1", IntField: 1}})
+	destinationPath := filepath.Join(s.tc.C.TempDir(), "clone.sqlite")
+	destinationTc := utils.NewTestContext(s.T(), destinationPath, "")
+	defer destinationTc.Close()
+	destinationTc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "old", IntField: 0}})
+
+	_, errS, err := s.tc.ExecuteShell([]string{".clone " + destinationPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Contains, "failed to create table simple_table in the destination")
+	s.tc.Assert(errS, qt.Contains, "Use truncate")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".clone --truncate " + destinationPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "Cloned 1 tables and 1 rows into "+destinationPath)
+
+	outS, _, err = destinationTc.ExecuteShell([]string{"SELECT textField FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"textField"}, [][]string{{"value1"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnAutoincrementTable_WhenCallDotCloneCommand_ExpectCounterCopied() {
+	_, _, err := s.tc.Execute(`CREATE TABLE counted (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+		INSERT INTO counted (name) VALUES ('a'), ('b'), ('c');
+		DELETE FROM counted WHERE id = 3;`)
+	s.tc.Assert(err, qt.IsNil)
+	destinationPath := filepath.Join(s.tc.C.TempDir(), "clone.sqlite")
+
+	_, errS, err := s.tc.ExecuteShell([]string{".clone " + destinationPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	destinationTc := utils.NewTestContext(s.T(), destinationPath, "")
+	defer destinationTc.Close()
+	outS, errS, err := destinationTc.ExecuteShell([]string{".mode csv", "INSERT INTO counted (name) VALUES ('d') RETURNING id;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "id\n4")
+}
+
 func (s *DBRootCommandShellSuite) Test_GivenATableWithRecordsWithSingleQuote_WhenCalllSelectAllFromTable_ExpectSingleQuoteScape() {
 	s.tc.CreateEmptySimpleTable("t")
 	_, errS, err := s.tc.Execute("INSERT INTO t VALUES (0, \"x'x\", 0)")
@@ -351,6 +729,530 @@ func (s *DBRootCommandShellSuite) Test_GivenAnEmptyTable_WhenCallDotModeJSONAndS
 	s.tc.Assert(outS, qt.Equals, "")
 }
 
+func (s *DBRootCommandShellSuite) Test_GivenAnAttachedDatabase_WhenCallSchemaCommands_ExpectObjectsOfBothDatabases() {
+	if !strings.HasSuffix(s.dbUri, "test.sqlite") {
+		s.T().Skip("ATTACH is only supported by local databases")
+	}
+	s.tc.CreateEmptySimpleTable("main_table")
+
+	attachedPath := filepath.Join(s.tc.C.TempDir(), "attached.sqlite")
+	_, errS, err := s.tc.ExecuteShell([]string{
+		"ATTACH DATABASE '" + attachedPath + "' AS aux;",
+		"CREATE TABLE aux.aux_table (id INTEGER PRIMARY KEY, value TEXT);",
+		"CREATE INDEX aux.aux_index ON aux_table (value);",
+	})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	defer func() {
+		_, _, err := s.tc.Execute("DETACH DATABASE aux;")
+		s.tc.Assert(err, qt.IsNil)
+	}()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".databases"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"name", "file"}, [][]string{{"main", s.dbUri}, {"aux", attachedPath}}))
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".tables"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"aux.aux_table\nmain_table"}}))
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".schema aux.%"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"CREATE TABLE aux_table (id INTEGER PRIMARY KEY, value TEXT);\nCREATE INDEX aux_index ON aux_table (value);"}}))
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".indexes aux.aux_table"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"aux.aux_index"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithConstraints_WhenCallDotDescribe_ExpectColumnsWithConstraintsAndIndexes() {
+	_, _, err := s.tc.Execute(`CREATE TABLE parent (id INTEGER PRIMARY KEY);
+		CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER NOT NULL REFERENCES parent(id) ON DELETE CASCADE, name TEXT DEFAULT 'none');
+		CREATE UNIQUE INDEX child_name ON child (name, parent_id);`)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".describe child"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput(
+		[]string{"name", "type", "not_null", "default", "primary_key", "kind", "references", "indexes"},
+		[][]string{
+			{"id", "INTEGER", "false", "NULL", "1", "normal", "", ""},
+			{"parent_id", "INTEGER", "true", "NULL", "0", "normal", "parent(id) ON DELETE CASCADE", "child_name (unique)"},
+			{"name", "TEXT", "false", "'none'", "0", "normal", "", "child_name (unique)"},
+		},
+	))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithAGeneratedColumn_WhenCallDotDescribeInJSONMode_ExpectColumnKindInJSON() {
+	_, _, err := s.tc.Execute("CREATE TABLE generated (name TEXT, upper_name TEXT GENERATED ALWAYS AS (upper(name)) VIRTUAL);")
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode json", ".describe generated"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	var columns []map[string]string
+	s.tc.Assert(json.Unmarshal([]byte(outS), &columns), qt.IsNil)
+	s.tc.Assert(columns, qt.HasLen, 2)
+	s.tc.Assert(columns[1]["name"], qt.Equals, "upper_name")
+	s.tc.Assert(columns[1]["kind"], qt.Equals, "generated virtual")
+}
+
+func (s *DBRootCommandShellSuite) Test_WhenCallDotDescribeWithAMissingTable_ExpectError() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".describe missing_table"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Error: table missing_table does not exist")
+	s.tc.Assert(outS, qt.Equals, "")
+}
+
+func (s *DBRootCommandShellSuite) createErdTables() {
+	_, _, err := s.tc.Execute(`CREATE TABLE parent (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER NOT NULL REFERENCES parent(id));
+		CREATE TABLE toy (id INTEGER PRIMARY KEY, child_id INTEGER REFERENCES child(id));
+		CREATE TABLE unrelated (value);`)
+	s.tc.Assert(err, qt.IsNil)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenTablesWithForeignKeys_WhenCallDotErd_ExpectMermaidDiagram() {
+	s.createErdTables()
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".erd"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `erDiagram
+    child {
+        INTEGER id PK
+        INTEGER parent_id FK
+    }
+    parent {
+        INTEGER id PK
+        TEXT name
+    }
+    toy {
+        INTEGER id PK
+        INTEGER child_id FK
+    }
+    unrelated {
+        ANY value
+    }
+    parent ||--o{ child : "parent_id"
+    child |o--o{ toy : "child_id"`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenTablesWithForeignKeys_WhenCallDotErdWithDotFormatAndTablesPattern_ExpectDiagramOfTheTableAndItsNeighborsInFile() {
+	s.createErdTables()
+	diagramPath := filepath.Join(s.tc.C.TempDir(), "schema.dot")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".erd --format dot --tables parent " + diagramPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "")
+
+	diagram, err := os.ReadFile(diagramPath)
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(string(diagram), qt.Equals, `digraph schema {
+    rankdir=LR;
+    node [shape=record];
+    "child" [label="{child|id INTEGER (PK)\lparent_id INTEGER (FK)\l}"];
+    "parent" [label="{parent|id INTEGER (PK)\lname TEXT\l}"];
+    "child" -> "parent" [label="parent_id"];
+}
+`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithAnIndex_WhenCallDotExplain_ExpectQueryPlanTree() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+	_, _, err := s.tc.Execute("CREATE INDEX simple_table_int ON simple_table (intField);")
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".explain SELECT * FROM simple_table WHERE intField = 1 AND id IN (SELECT id FROM simple_table WHERE textField = 'a');"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `QUERY PLAN
+|--SEARCH simple_table USING INDEX simple_table_int (intField=? AND rowid=?)
+`+"`"+`--LIST SUBQUERY 1
+   `+"`"+`--SCAN simple_table`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATable_WhenCallDotExplainWithBytecode_ExpectLoopBodyIndented() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".explain --bytecode SELECT textField FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Matches, `(?s).*\n\d+\s+Rewind .*\n\d+\s{2,}  Column .*\n\d+\s+Next .*`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAStringWithSpaces_WhenCallDotExplainWithBytecode_ExpectSpacesKept() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".explain --bytecode SELECT 'a   b';"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Contains, "a   b")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenEqpOn_WhenExecuteAStatement_ExpectQueryPlanBeforeTheResults() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value", IntField: 1}})
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".eqp on", "SELECT textField FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "QUERY PLAN\n`--SCAN simple_table\n"+utils.GetPrintTableOutput([]string{"textField"}, [][]string{{"value"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenATableWithRecords_WhenCallDotStatsInJSONMode_ExpectDatabaseAndTableSizes() {
+	s.tc.CreateSimpleTable("simple_table", []utils.SimpleTableEntry{{TextField: "value", IntField: 1}, {TextField: "value2", IntField: 2}})
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode json", ".stats"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	lines := strings.Split(outS, "\n")
+	s.tc.Assert(lines, qt.HasLen, 2)
+
+	var databaseStats []map[string]string
+	s.tc.Assert(json.Unmarshal([]byte(lines[0]), &databaseStats), qt.IsNil)
+	s.tc.Assert(databaseStats, qt.HasLen, 1)
+	s.tc.Assert(databaseStats[0]["page_size"], qt.Not(qt.Equals), "0")
+
+	var objectStats []map[string]string
+	s.tc.Assert(json.Unmarshal([]byte(lines[1]), &objectStats), qt.IsNil)
+	s.tc.Assert(objectStats, qt.HasLen, 1)
+	s.tc.Assert(objectStats[0]["name"], qt.Equals, "simple_table")
+	s.tc.Assert(objectStats[0]["rows"], qt.Equals, "2")
+	if strings.HasSuffix(s.dbUri, "test.sqlite") {
+		// the sqlite3 driver is built without dbstat, so the size is the length of the stored values
+		s.tc.Assert(objectStats[0]["estimated"], qt.Equals, "true")
+		s.tc.Assert(objectStats[0]["bytes"], qt.Equals, "15")
+	}
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenASchemaWithIssues_WhenCallDotLint_ExpectAllIssuesReported() {
+	_, _, err := s.tc.Execute(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id TEXT REFERENCES users(id), title TEXT, created_at INTEGER);
+		CREATE INDEX posts_title ON posts (title);
+		CREATE INDEX posts_title_created ON posts (title, created_at);
+		CREATE TABLE logs (message TEXT);`)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", ".lint"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `check,object,message,suggestion
+missing_primary_key,logs,"table logs has no primary key, so its rows can only be identified by the rowid",
+foreign_key_without_index,posts,"foreign key posts(user_id) referencing users has no index, so deleting or updating users rows scans posts","CREATE INDEX ""posts_user_id_idx"" ON ""posts"" (""user_id"");"
+redundant_index,posts_title,"index posts_title on posts(title) is covered by index posts_title_created on posts(title, created_at)","DROP INDEX ""posts_title"";"
+inconsistent_type,posts.user_id,"column posts.user_id is TEXT but references users.id, which is INTEGER",`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAStatementScanningATable_WhenCallDotLintWithTheStatement_ExpectSuggestedIndex() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", ".lint SELECT * FROM simple_table t WHERE t.intField > 1 AND textField = 'value';"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `check,object,message,suggestion
+full_table_scan,simple_table,the statement scans every row of simple_table,"CREATE INDEX ""simple_table_textField_intField_idx"" ON ""simple_table"" (""textField"", ""intField"");"`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenASchemaWithoutIssues_WhenCallDotLint_ExpectNoIssues() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".lint"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "No issues found")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenUnrelatedColumnsSharingANameWithDifferentTypes_WhenCallDotLint_ExpectNoIssues() {
+	_, _, err := s.tc.Execute(`CREATE TABLE events (id INTEGER PRIMARY KEY, code INTEGER);
+		CREATE TABLE countries (id INTEGER PRIMARY KEY, code TEXT);`)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".lint"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "No issues found")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnotherDatabaseWithADifferentSchema_WhenCallDotSchemaDiff_ExpectMigrationThatMakesSchemasIdentical() {
+	_, _, err := s.tc.Execute(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, body TEXT);
+		CREATE INDEX posts_title ON posts (title);
+		CREATE TABLE old_table (value TEXT);
+		INSERT INTO posts (title, body) VALUES ('title', 'body');`)
+	s.tc.Assert(err, qt.IsNil)
+
+	otherPath := filepath.Join(s.tc.C.TempDir(), "other.sqlite")
+	_, _, err = utils.ExecuteCobraCommand(s.T(), cmd.NewRootCmd(), "--exec", `CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, name TEXT DEFAULT 'none');
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT NOT NULL);
+		CREATE INDEX posts_title ON posts (title);
+		CREATE TABLE new_table (value INTEGER);`, otherPath)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".schemadiff " + otherPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+DROP TABLE "old_table";
+ALTER TABLE "users" ADD COLUMN name TEXT DEFAULT 'none';
+CREATE TABLE "new_posts" (id INTEGER PRIMARY KEY, title TEXT NOT NULL);
+INSERT INTO "new_posts" ("id", "title") SELECT "id", "title" FROM "posts";
+DROP TABLE "posts";
+ALTER TABLE "new_posts" RENAME TO "posts";
+CREATE TABLE new_table (value INTEGER);
+CREATE INDEX posts_title ON posts (title);
+PRAGMA foreign_key_check;
+COMMIT;`)
+
+	_, errS, err = s.tc.ExecuteShell([]string{outS})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".schemadiff " + otherPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "-- the schemas are identical")
+
+	outS, errS, err = s.tc.ExecuteShell([]string{"SELECT * FROM posts;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id", "title"}, [][]string{{"1", "title"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenForeignKeysOn_WhenCallDotSchemaDiffRebuildingATable_ExpectForeignKeysSwitchedOnAgain() {
+	_, _, err := s.tc.Execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, body TEXT);")
+	s.tc.Assert(err, qt.IsNil)
+
+	otherPath := filepath.Join(s.tc.C.TempDir(), "other.sqlite")
+	_, _, err = utils.ExecuteCobraCommand(s.T(), cmd.NewRootCmd(), "--exec", "CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);", otherPath)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{"PRAGMA foreign_keys=ON;", ".schemadiff " + otherPath})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(strings.HasPrefix(outS, "PRAGMA foreign_keys=OFF;\n"), qt.IsTrue)
+	s.tc.Assert(strings.HasSuffix(outS, "COMMIT;\nPRAGMA foreign_keys=ON;"), qt.IsTrue)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenADirectoryWithMigrations_WhenCallDotMigrate_ExpectPendingMigrationsAppliedOnce() {
+	dir := s.tc.C.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "0001_users.sql"), []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\nINSERT INTO users (name) VALUES ('a; b');\n"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+	err = os.WriteFile(filepath.Join(dir, "0002_email.sql"), []byte("ALTER TABLE users ADD COLUMN email TEXT;"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".migrate --dry-run " + dir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Contains, "-- 0002_email\nALTER TABLE users ADD COLUMN email TEXT;")
+
+	outS, errS, err = s.tc.ExecuteShell([]string{".migrate " + dir, ".migrate " + dir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "Applied 0001_users\nApplied 0002_email\nThe database is up to date")
+
+	outS, errS, err = s.tc.ExecuteShell([]string{"SELECT name, email FROM users;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"name", "email"}, [][]string{{"a; b", "NULL"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnAppliedMigrationThatWasModified_WhenCallDotMigrate_ExpectErrorAndNothingApplied() {
+	dir := s.tc.C.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "0001_users.sql"), []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+
+	_, errS, err := s.tc.ExecuteShell([]string{".migrate " + dir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+
+	err = os.WriteFile(filepath.Join(dir, "0001_users.sql"), []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+	err = os.WriteFile(filepath.Join(dir, "0002_posts.sql"), []byte("CREATE TABLE posts (id INTEGER PRIMARY KEY);"), 0644)
+	s.tc.Assert(err, qt.IsNil)
+
+	_, errS, err = s.tc.ExecuteShell([]string{".migrate " + dir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Error: migration 0001_users was modified after being applied. Create a new migration instead of changing it")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", ".migrate status " + dir})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Matches, "version,state,applied_at\n0001_users,modified,[0-9: -]+\n0002_posts,pending,")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenBailOff_WhenExecuteStatementsWithErrors_ExpectEachErrorReportedAndRemainingStatementsExecuted() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".bail off", "INSERT INTO simple_table VALUES (1, 'one', 1); INSERT INTO missing_table VALUES (1); INSERT INTO simple_table VALUES (1, 'again', 2); SELECT textField FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Matches, `Error: statement 2 failed: .*missing_table.*
+  INSERT INTO missing_table VALUES \(1\)
+Error: statement 3 failed: .*UNIQUE constraint failed.*
+  INSERT INTO simple_table VALUES \(1, 'again', 2\)
+Error: 2 of 4 statements failed`)
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"textField"}, [][]string{{"one"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenBailOn_WhenExecuteStatementsWithErrors_ExpectStopAtTheFirstError() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".bail off", ".bail on", "INSERT INTO missing_table VALUES (1); SELECT 1;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Contains, "missing_table")
+	s.tc.Assert(errS, qt.Not(qt.Contains), "statements failed")
+	s.tc.Assert(outS, qt.Equals, "")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenEchoOn_WhenExecuteMultipleStatements_ExpectEachStatementPrintedBeforeItsResults() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".echo on", "SELECT 1 AS a; SELECT 2 AS b;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Matches, "SELECT 1 AS a;\nA *\n1 *\nSELECT 2 AS b;\nB *\n2")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenEchoOnAndJSONMode_WhenExecuteMultipleStatements_ExpectResultsGroupedByStatement() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".echo on", ".mode json", "INSERT INTO simple_table VALUES (1, 'one', 1); SELECT textField FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `{"statement":"INSERT INTO simple_table VALUES (1, 'one', 1)","rows":[]}
+{"statement":"SELECT textField FROM simple_table","rows":[{"textField":"one"}]}`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenParametersSet_WhenExecuteStatementsWithPlaceholders_ExpectValuesBound() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{
+		".param set :id 42",
+		".param set @text 'it''s'",
+		".param set 3 -1",
+		"INSERT INTO simple_table VALUES (:id, @text, ?3);",
+		"SELECT id, textField, intField, $unset IS NULL AS unset FROM simple_table WHERE id = :id AND textField = @text;",
+	})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"id", "textField", "intField", "unset"}, [][]string{{"42", "it's", "-1", "1"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenParametersSet_WhenCallDotParamListAndClear_ExpectParametersListedThenRemoved() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".param set :id 42", ".param set 1 text", ".param set @gone 1", ".param unset @gone", ".mode csv", ".param list", ".param clear", ".param list"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "name,value\n:id,42\n?1,text\nname,value")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAQuotedValueWithSpaces_WhenCallDotParamSet_ExpectSpacesKept() {
+	outS, errS, err := s.tc.ExecuteShell([]string{".param set :text 'a   b  c'", ".param set :real inf", ".mode csv", "SELECT :text, typeof(:real);"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, ":text,typeof(:real)\na   b  c,text")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenUnboundParametersAndInputThatIsNotATerminal_WhenExecuteStatement_ExpectNullsBoundAndNextLinesExecuted() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{
+		"INSERT INTO simple_table VALUES (1, :text, ?3);",
+		".mode csv",
+		"SELECT id, textField IS NULL, intField IS NULL FROM simple_table;",
+	})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "id,textField IS NULL,intField IS NULL\n1,1,1")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenOpenTransactionWithSavepoints_WhenCallDotTx_ExpectTransactionStack() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", "BEGIN;", "SAVEPOINT outer_sp;", "SAVEPOINT inner_sp;", ".tx", "RELEASE inner_sp;", ".tx", "ROLLBACK;", ".tx"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, `level,type,savepoint
+1,transaction,
+2,savepoint,outer_sp
+3,savepoint,inner_sp
+
+level,type,savepoint
+1,transaction,
+2,savepoint,outer_sp
+
+No transaction is open`)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenOpenTransactionWithoutTerminal_WhenQuit_ExpectChangesRolledBackWithoutAsking() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	// the input isn't a terminal, so the line after .quit isn't taken as the answer
+	_, errS, err := s.tc.ExecuteShell([]string{"BEGIN;", "INSERT INTO simple_table VALUES (1, 'one', 1);", ".quit", "commit"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Warning: the open transaction of the database was rolled back")
+
+	outS, _, err := s.tc.ExecuteShell([]string{"SELECT count(*) AS count FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"count"}, [][]string{{"0"}}))
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenOpenTransaction_WhenInputEnds_ExpectChangesRolledBackWithWarning() {
+	s.tc.CreateEmptySimpleTable("simple_table")
+
+	_, errS, err := s.tc.ExecuteShell([]string{"BEGIN;", "INSERT INTO simple_table VALUES (1, 'one', 1);"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "Warning: the open transaction of the database was rolled back")
+
+	outS, _, err := s.tc.ExecuteShell([]string{"SELECT count(*) AS count FROM simple_table;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"count"}, [][]string{{"0"}}))
+}
+
+func (s *DBRootCommandShellSuite) setEditor(script string) {
+	editorPath := filepath.Join(s.tc.C.TempDir(), "editor.sh")
+	err := os.WriteFile(editorPath, []byte("#!/bin/sh\n"+script+"\n"), 0755)
+	s.tc.Assert(err, qt.IsNil)
+	s.T().Setenv("EDITOR", editorPath)
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnExecutedStatement_WhenCallDotEditCommand_ExpectTheEditedStatementExecuted() {
+	s.setEditor(`sed -i 's/1 AS a/2 AS b/' "$1"`)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", "SELECT 1 AS a;", ".edit"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "a\n1\nb\n2")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAStatementBeingTyped_WhenCallDotEditCommand_ExpectItFinishedInTheEditor() {
+	s.setEditor(`printf ' 3 AS c;\n' >> "$1"`)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", "SELECT", ".edit", "SELECT 4 AS d;"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "c\n3\nd\n4")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnEditorLeavingTheFileEmpty_WhenCallDotEditCommand_ExpectNothingExecuted() {
+	s.setEditor(`: > "$1"`)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".mode csv", "SELECT 1 AS a;", ".edit"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "")
+	s.tc.Assert(outS, qt.Equals, "a\n1")
+}
+
+func (s *DBRootCommandShellSuite) Test_GivenAnEditorWritingMessages_WhenCallDotEditCommand_ExpectThemOnTheShellStreams() {
+	s.setEditor(`echo opened; echo warned >&2; : > "$1"`)
+
+	outS, errS, err := s.tc.ExecuteShell([]string{".edit"})
+	s.tc.Assert(err, qt.IsNil)
+	s.tc.Assert(errS, qt.Equals, "warned")
+	s.tc.Assert(outS, qt.Equals, "opened")
+}
+
 func (s *DBRootCommandShellSuite) Test_WhenCallACommandThatDoesNotExist_ExpectToReturnAnErrorMessage() {
 	outS, errS, err := s.tc.ExecuteShell([]string{".nonExistingCommand"})
 	s.tc.Assert(err, qt.IsNil)
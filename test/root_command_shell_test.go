@@ -1,11 +1,20 @@
 package main_test
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/libsql/libsql-shell-go/internal/cmd"
+	"github.com/libsql/libsql-shell-go/internal/db"
+	"github.com/libsql/libsql-shell-go/internal/shellcmd"
 	"github.com/libsql/libsql-shell-go/test/utils"
 )
 
@@ -96,3 +105,254 @@ func TestRootCommandShellSuite_WhenDbIsSqld(t *testing.T) {
 
 	suite.Run(t, NewRootCommandShellSuite(testConfig.SqldDbUri, testConfig.AuthToken))
 }
+
+func TestRootCommandShell_WhenCallDotOpen_ExpectFollowingStatementsToRunOnTheNewDatabase(t *testing.T) {
+	c := qt.New(t)
+
+	firstDbPath := filepath.Join(c.TempDir(), "first.sqlite")
+	secondDbPath := filepath.Join(c.TempDir(), "second.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE second_table (id INTEGER PRIMARY KEY);", secondDbPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".open "+secondDbPath+"\n.tables\n", "--quiet", firstDbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "")
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"second_table"}}))
+}
+
+func TestRootCommandShell_WhenCallDotOpenWithAnInvalidDatabase_ExpectCurrentDatabaseToBeKept(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY);", dbPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".open "+filepath.Join(c.TempDir(), "missing", "db.sqlite")+"\n.tables\n", "--quiet", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Not(qt.Equals), "")
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"test"}}))
+}
+
+func TestRootCommandShell_WhenCallDotConnect_ExpectToSwitchBetweenNamedConnections(t *testing.T) {
+	c := qt.New(t)
+
+	defaultDbPath := filepath.Join(c.TempDir(), "default.sqlite")
+	stagingDbPath := filepath.Join(c.TempDir(), "staging.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE default_table (id INTEGER PRIMARY KEY);", defaultDbPath)
+	c.Assert(err, qt.IsNil)
+	_, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE staging_table (id INTEGER PRIMARY KEY);", stagingDbPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".connect staging "+stagingDbPath+"\n.tables\n", "--quiet", defaultDbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "")
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"staging_table"}}))
+
+	outS, errS, err = utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".connect staging "+stagingDbPath+"\n.connect default\n.tables\n", "--quiet", defaultDbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "")
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{""}, [][]string{{"default_table"}}))
+
+	outS, errS, err = utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".connect staging "+stagingDbPath+"\n.connections\n", "--quiet", defaultDbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "")
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"active", "name", "database"}, [][]string{{"", "default", defaultDbPath}, {"*", "staging", stagingDbPath}}))
+}
+
+func TestRootCommandShell_WhenCallDotConnectWithAnUnknownName_ExpectError(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+
+	_, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".connect production\n", "--quiet", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, `Error: connection production does not exist. Use ".connect production URL_OR_PATH" to open it`)
+}
+
+func TestRootCommandShell_WhenExecWithEcho_ExpectEachStatementPrintedBeforeItsResults(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--echo", "--exec", "SELECT 1 AS a; SELECT 2 AS b;", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Matches, "SELECT 1 AS a;\nA *\n1 *\nSELECT 2 AS b;\nB *\n2")
+}
+
+func TestRootCommandShell_WhenExecLeavesATransactionOpen_ExpectChangesRolledBackWithWarning(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+
+	_, errS, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER); BEGIN; INSERT INTO test VALUES (1);", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "Warning: the open transaction of the database was rolled back")
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "SELECT count(*) AS count FROM test;", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"count"}, [][]string{{"0"}}))
+}
+
+func TestRootCommandShell_WhenExecReadsTheStandardInput_ExpectItsStatementsAndCommandsExecuted(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+
+	outS, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), ".mode csv\nSELECT 1 AS a;\n", "--exec", ".read -", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Equals, "")
+	c.Assert(outS, qt.Equals, "a\n1")
+}
+
+// newStubRemoteServer answers the statements of the libsql HTTP protocol with empty results, and /dump with the status.
+// The body of each request of statements is appended to requests, unless it's nil
+func newStubRemoteServer(t *testing.T, dumpStatus int, requests *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dump" {
+			w.WriteHeader(dumpStatus)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if requests != nil {
+			*requests = append(*requests, string(body))
+		}
+
+		var pipeline struct {
+			Requests []json.RawMessage `json:"requests"`
+		}
+		if err := json.Unmarshal(body, &pipeline); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		result := map[string]interface{}{"type": "ok", "response": map[string]interface{}{"type": "execute", "result": map[string]interface{}{
+			"cols": []interface{}{}, "rows": []interface{}{}, "affected_row_count": 0, "last_insert_rowid": nil,
+		}}}
+		results := make([]interface{}, len(pipeline.Requests))
+		for i := range results {
+			results[i] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"baton": nil, "base_url": nil, "results": results})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRootCommandShell_WhenDumpEndpointRefusesTheCredentials_ExpectErrorWithoutFallingBack(t *testing.T) {
+	c := qt.New(t)
+
+	server := newStubRemoteServer(t, http.StatusUnauthorized, nil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", ".dump", server.URL)
+	c.Assert(err, qt.ErrorMatches, "remote /dump endpoint refused the credentials: 401 Unauthorized. Check the auth token")
+	c.Assert(outS, qt.Equals, "")
+}
+
+func TestRootCommandShell_WhenDumpEndpointIsMissing_ExpectStatementBasedDump(t *testing.T) {
+	c := qt.New(t)
+
+	server := newStubRemoteServer(t, http.StatusNotFound, nil)
+
+	outS, errS, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", ".dump", server.URL)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Not(qt.Contains), "Error")
+	c.Assert(outS, qt.Contains, "BEGIN TRANSACTION;")
+}
+
+func TestRootCommandShell_GivenTransactionOverHttp_ExpectParametersBoundWhenTypedAndCommandsNotBuffered(t *testing.T) {
+	c := qt.New(t)
+
+	requests := make([]string, 0)
+	server := newStubRemoteServer(t, http.StatusOK, &requests)
+
+	_, errS, err := utils.ExecuteCobraCommandWithInitialInput(t, cmd.NewRootCmd(), strings.Join([]string{
+		"BEGIN;",
+		".param set :text 'it''s'",
+		"INSERT INTO t VALUES (:text, ?2);",
+		".param clear",
+		".tables",
+		"COMMIT;",
+	}, "\n"), server.URL)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errS, qt.Not(qt.Contains), "Error")
+
+	// the first request tests the connection
+	c.Assert(requests, qt.HasLen, 3)
+	c.Assert(requests[1], qt.Contains, "order by name")
+	c.Assert(requests[2], qt.Contains, `INSERT INTO t VALUES ('it''s', NULL)`)
+}
+
+func TestApplyMigration_GivenHttpDatabase_ExpectMigrationAndItsRecordSentAsOneTransaction(t *testing.T) {
+	c := qt.New(t)
+
+	requests := make([]string, 0)
+	server := newStubRemoteServer(t, http.StatusOK, &requests)
+
+	database, err := db.NewDb(server.URL, "", "", false, "")
+	c.Assert(err, qt.IsNil)
+	defer database.Close()
+
+	err = database.ApplyMigration(db.Migration{Version: "0001_test", Checksum: "abc", Sql: "CREATE TABLE a (id INTEGER);\nCREATE TABLE b (id INTEGER);"})
+	c.Assert(err, qt.IsNil)
+
+	// the last request is the migration, after the one creating the tracking table
+	last := requests[len(requests)-1]
+	c.Assert(last, qt.Matches, `(?s).*"BEGIN".*CREATE TABLE a.*CREATE TABLE b.*INSERT INTO `+db.MigrationsTable+` \(version, checksum\) VALUES \('0001_test', 'abc'\).*"COMMIT".*`)
+}
+
+func TestRootCommandShell_GivenARemoteEncryptionKey_WhenConnectToAnotherDatabase_ExpectKeySentToItToo(t *testing.T) {
+	c := qt.New(t)
+
+	first := newStubRemoteServer(t, http.StatusOK, nil)
+	stub := newStubRemoteServer(t, http.StatusOK, nil)
+	keys := make([]string, 0)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("x-turso-encryption-key"))
+		stub.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(other.Close)
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--remote-encryption-key", "secret", "--exec", ".connect other "+other.URL, first.URL)
+	c.Assert(err, qt.IsNil)
+	c.Assert(keys, qt.Not(qt.HasLen), 0)
+	for _, key := range keys {
+		c.Assert(key, qt.Equals, "secret")
+	}
+}
+
+func TestRootCommandShell_WhenCloneIntoTheSameFileWrittenDifferently_ExpectError(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	dbPath := filepath.Join(dir, "test.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", ".clone --truncate "+dir+"/./test.sqlite", dbPath)
+	c.Assert(err, qt.ErrorMatches, "the destination must be a different database")
+}
+
+func TestDotPrompt_GivenATemplateWithSpaces_ExpectSpacesKept(t *testing.T) {
+	c := qt.New(t)
+
+	line := `.prompt "{db}   ({mode})  > "`
+	var template string
+	var out strings.Builder
+	config := &shellcmd.DbCmdConfig{
+		OutF:              &out,
+		ErrF:              &out,
+		GetCommandLine:    func() string { return line },
+		SetPromptTemplate: func(value string) { template = value },
+	}
+
+	rootCmd := shellcmd.NewDatabaseRootCmd(config)
+	rootCmd.SetArgs(strings.Fields(line))
+	c.Assert(rootCmd.Execute(), qt.IsNil)
+	c.Assert(template, qt.Equals, "{db}   ({mode})  > ")
+}
@@ -0,0 +1,140 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/libsql/libsql-shell-go/internal/cmd"
+	"github.com/libsql/libsql-shell-go/test/utils"
+)
+
+func TestRootCommandBackup_GivenADbWithRecords_ExpectBackupFileWithTheRecords(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	backupPath := filepath.Join(c.TempDir(), "backup.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY, value TEXT); INSERT INTO test VALUES (1, 'one');", dbPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "backup", dbPath, backupPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "Backup of 1 tables and 1 rows written to "+backupPath)
+
+	outS, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "SELECT value FROM test;", backupPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"value"}, [][]string{{"one"}}))
+}
+
+func TestRootCommandBackup_GivenAPathWithSpaces_ExpectBackupFileWrittenThere(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	backupPath := filepath.Join(c.TempDir(), "my backups", "backup copy.sqlite")
+	c.Assert(os.Mkdir(filepath.Dir(backupPath), 0755), qt.IsNil)
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY);", dbPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "backup", dbPath, backupPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "Backup of 1 tables and 0 rows written to "+backupPath)
+}
+
+func TestRootCommandSchemaDiff_GivenTwoDatabases_ExpectSQLMigratingTheFirstIntoTheSecond(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	targetPath := filepath.Join(c.TempDir(), "target.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY);", dbPath)
+	c.Assert(err, qt.IsNil)
+	_, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY, value TEXT);", targetPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "schemadiff", dbPath, targetPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "BEGIN TRANSACTION;\nALTER TABLE \"test\" ADD COLUMN value TEXT;\nCOMMIT;")
+}
+
+func TestRootCommandSchemaDiff_GivenATargetPathWithSpaces_ExpectSQLMigratingIntoIt(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	targetPath := filepath.Join(c.TempDir(), "my target.sqlite")
+
+	_, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY);", dbPath)
+	c.Assert(err, qt.IsNil)
+	_, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "CREATE TABLE test (id INTEGER PRIMARY KEY, value TEXT);", targetPath)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "schemadiff", dbPath, targetPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "BEGIN TRANSACTION;\nALTER TABLE \"test\" ADD COLUMN value TEXT;\nCOMMIT;")
+}
+
+func TestRootCommandMigrate_GivenADirectoryWithMigrations_ExpectMigrationsAppliedAndListedByStatus(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	dir := c.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "0001_test.sql"), []byte("CREATE TABLE test (id INTEGER PRIMARY KEY);"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "migrate", "status", dbPath, dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"version", "state", "applied_at"}, [][]string{{"0001_test", "pending", ""}}))
+
+	outS, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "migrate", dbPath, dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "Applied 0001_test")
+}
+
+func TestRootCommandMigrate_GivenADirectoryWithSpacesAndDryRun_ExpectStatementsPrinted(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	dir := filepath.Join(c.TempDir(), "my migrations")
+	c.Assert(os.Mkdir(dir, 0755), qt.IsNil)
+	err := os.WriteFile(filepath.Join(dir, "0001_test.sql"), []byte("CREATE TABLE test (id INTEGER PRIMARY KEY);"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "migrate", "--dry-run", dbPath, dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, "-- 0001_test\nCREATE TABLE test (id INTEGER PRIMARY KEY);")
+}
+
+func TestRootCommandMigrate_GivenAMigrationWithItsOwnTransaction_ExpectErrorAndNothingApplied(t *testing.T) {
+	c := qt.New(t)
+
+	dbPath := filepath.Join(c.TempDir(), "test.sqlite")
+	dir := c.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "0001_test.sql"), []byte("BEGIN;\nCREATE TABLE test (id INTEGER PRIMARY KEY);\nCOMMIT;"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	_, _, err = utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "migrate", dbPath, dir)
+	c.Assert(err, qt.ErrorMatches, "failed to apply migration 0001_test: migrations are applied in a transaction of their own, remove the BEGIN statement from 0001_test.sql")
+
+	outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "SELECT count(*) AS tables FROM sqlite_schema WHERE name = 'test';", dbPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"tables"}, [][]string{{"0"}}))
+}
+
+func TestRootCommand_GivenDatabasesNamedLikeSubcommands_ExpectThemOpenedAsDatabases(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	defer func() { c.Assert(os.Chdir(wd), qt.IsNil) }()
+
+	for _, dbPath := range []string{"./backup", "help", "completion"} {
+		outS, _, err := utils.ExecuteCobraCommand(t, cmd.NewRootCmd(), "--exec", "SELECT 1 AS one;", dbPath)
+		c.Assert(err, qt.IsNil, qt.Commentf("database %s", dbPath))
+		c.Assert(outS, qt.Equals, utils.GetPrintTableOutput([]string{"one"}, [][]string{{"1"}}))
+	}
+}
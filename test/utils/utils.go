@@ -2,6 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
 	"strings"
 	"testing"
 
@@ -41,3 +44,24 @@ func GetPrintTableOutput(header []string, data [][]string) string {
 
 	return strings.TrimSpace(buf.String())
 }
+
+func ReadGzipFile(t *testing.T, filePath string) string {
+	t.Helper()
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Fail to open gzip file. err: %v", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Fail to read gzip header. err: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Fail to decompress gzip file. err: %v", err)
+	}
+
+	return string(content)
+}